@@ -3,6 +3,7 @@ package context
 import (
 	ilog "github.com/One-com/gonelog"
 	"github.com/One-com/gonelog/log"
+	"github.com/One-com/gonelog/syslog"
 	goctx "golang.org/x/net/context"
 	"time"
 )
@@ -59,7 +60,11 @@ func WithValue(parent goctx.Context, key interface{}, val interface{}) Context {
 	return &context{gc, l}
 }
 
+// WithLogging returns a Context logging to l, forcing any level forced by parent.
 func WithLogging(parent goctx.Context, l *log.Logger) Context {
+	if l != nil {
+		l = l.ForContext(parent)
+	}
 	return &context{parent, l}
 }
 
@@ -67,6 +72,17 @@ func WithLoggedValue(parent goctx.Context, key interface{}, val interface{}) Con
 	if p, ok := parent.(*context); ok {
 		return &context{parent, p.Logger.With(key, val)}
 	} else {
-		return &context{parent, log.Default().With(key, val)}
+		return &context{parent, log.Default().ForContext(parent).With(key, val)}
+	}
+}
+
+// WithForceLevel returns a Context logging at level regardless of the Logger level
+// (see log.ForceLevel()). The level is also forced on Loggers used with the Context
+// through log.ForContext(), like those of libraries.
+func WithForceLevel(parent goctx.Context, level syslog.Priority) Context {
+	gc := log.ContextWithForceLevel(parent, level)
+	if p, ok := parent.(*context); ok {
+		return &context{gc, p.Logger.ForceLevel(level)}
 	}
+	return &context{gc, log.Default().ForceLevel(level)}
 }
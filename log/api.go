@@ -17,33 +17,31 @@ func (l *Logger) Log(level syslog.Priority, msg string, kv ...interface{}) (err
 
 // Internal level loggers (to return from *ok() functions)
 // These are functionally equivalent to log() and output(), but bound to a specific level.
+// They go through log() to have the same number of stack frames as the other
+// log methods.
 func (l *Logger) alert(msg string, kv ...interface{}) {
-	level := syslog.LOG_ALERT
-	l.h.Log(l.newEvent(level, msg, normalize(kv)))
+	l.log(syslog.LOG_ALERT, msg, kv...)
 }
 func (l *Logger) crit(msg string, kv ...interface{}) {
-	level := syslog.LOG_CRIT
-	l.h.Log(l.newEvent(level, msg, normalize(kv)))
+	l.log(syslog.LOG_CRIT, msg, kv...)
 }
 func (l *Logger) error(msg string, kv ...interface{}) {
-	level := syslog.LOG_ERROR
-	l.h.Log(l.newEvent(level, msg, normalize(kv)))
+	l.log(syslog.LOG_ERROR, msg, kv...)
 }
 func (l *Logger) warn(msg string, kv ...interface{}) {
-	level := syslog.LOG_WARN
-	l.h.Log(l.newEvent(level, msg, normalize(kv)))
+	l.log(syslog.LOG_WARN, msg, kv...)
 }
 func (l *Logger) notice(msg string, kv ...interface{}) {
-	level := syslog.LOG_NOTICE
-	l.h.Log(l.newEvent(level, msg, normalize(kv)))
+	l.log(syslog.LOG_NOTICE, msg, kv...)
 }
 func (l *Logger) info(msg string, kv ...interface{}) {
-	level := syslog.LOG_INFO
-	l.h.Log(l.newEvent(level, msg, normalize(kv)))
+	l.log(syslog.LOG_INFO, msg, kv...)
 }
 func (l *Logger) debug(msg string, kv ...interface{}) {
-	level := syslog.LOG_DEBUG
-	l.h.Log(l.newEvent(level, msg, normalize(kv)))
+	l.log(syslog.LOG_DEBUG, msg, kv...)
+}
+func (l *Logger) trace(msg string, kv ...interface{}) {
+	l.log(LvlTRACE, msg, kv...)
 }
 
 //---
@@ -56,6 +54,7 @@ func (l *Logger) WARNok() (ilog.LogFunc, bool)   { return l.warn, l.Does(syslog.
 func (l *Logger) NOTICEok() (ilog.LogFunc, bool) { return l.notice, l.Does(syslog.LOG_NOTICE) }
 func (l *Logger) INFOok() (ilog.LogFunc, bool)   { return l.info, l.Does(syslog.LOG_INFO) }
 func (l *Logger) DEBUGok() (ilog.LogFunc, bool)  { return l.debug, l.Does(syslog.LOG_DEBUG) }
+func (l *Logger) TRACEok() (ilog.LogFunc, bool)  { return l.trace, l.Does(LvlTRACE) }
 
 //---
 
@@ -114,3 +113,11 @@ func (l *Logger) DEBUG(msg string, kv ...interface{}) {
 		l.log(lvl, msg, kv...)
 	}
 }
+
+// Log a message and optional KV values at TRACE level (below DEBUG).
+func (l *Logger) TRACE(msg string, kv ...interface{}) {
+	lvl := LvlTRACE
+	if l.Does(lvl) {
+		l.log(lvl, msg, kv...)
+	}
+}
@@ -220,3 +220,70 @@ func ExampleApplyHandlerOptions() {
 	// <3>PFX:fejl
 	// <4>PFX: (mylog) advarsel
 }
+
+func logWrapper(l *log.Logger, msg string) {
+	l.WithCallerSkip(1).ERROR(msg)
+}
+
+func ExampleLogger_WithCallerSkip() {
+	h := log.NewStdFormatter(log.SyncWriter(os.Stdout), "", log.Lshortfile|log.Lfunc|log.Lpackage)
+	l := log.NewLogger(syslog.LOG_WARNING, h)
+	l.DoCodeInfo(true)
+	logWrapper(l, "wrapped")
+	if f, ok := l.ERRORok(); ok {
+		f("direct")
+	}
+	// Output:
+	// api_test.go:232: github.com/One-com/gonelog/log_test.ExampleLogger_WithCallerSkip: wrapped
+	// api_test.go:234: github.com/One-com/gonelog/log_test.ExampleLogger_WithCallerSkip: direct
+}
+
+func ExampleFakeClock() {
+	clock := log.NewFakeClock(time.Date(2009, 1, 23, 1, 23, 23, 0, time.UTC))
+	clock.Step(time.Second)
+
+	h := log.NewStdFormatter(log.SyncWriter(os.Stdout), "", log.LstdFlags|log.LUTC)
+	l := log.NewLogger(syslog.LOG_WARNING, h)
+	l.SetClock(clock)
+	l.ERROR("first")
+	l.ERROR("second")
+
+	l.SetHandler(log.NewJSONFormatter(os.Stdout))
+	l.ERROR("json")
+	// Output:
+	// 2009/01/23 01:23:23 first
+	// 2009/01/23 01:23:24 second
+	// {"_lvl":3,"_msg":"json","_ts":"2009-01-23T01:23:25Z"}
+}
+
+func ExampleFacilityOpt() {
+	h := log.NewMinFormatter(log.SyncWriter(os.Stdout), log.FacilityOpt(syslog.LOG_LOCAL0))
+	l := log.NewLogger(syslog.LOG_WARNING, h)
+	l.ERROR("fejl")
+	l.ApplyHandlerOptions(log.FlagsOpt(log.Llevel | log.Lname))
+	l.WARN("advarsel")
+	// Output:
+	// <131>fejl
+	// <132> () advarsel
+}
+
+func ExampleLogger_In() {
+	h := log.NewStdFormatter(log.SyncWriter(os.Stdout), "", log.Llevel|log.Lcategory)
+	l := log.NewLogger(syslog.LOG_DEBUG, h)
+	sql := l.In("sql")
+	cache := l.In("cache")
+
+	sql.DEBUG("query", "rows", 3)
+	cache.DEBUG("miss")
+
+	// Only debug sql
+	l.SetCategories(sql.Category())
+	sql.DEBUG("query", "rows", 0)
+	cache.DEBUG("hit")
+	l.DEBUG("uncategorized")
+	// Output:
+	// <7>[sql] query rows=3
+	// <7>[cache] miss
+	// <7>[sql] query rows=0
+	// <7>uncategorized
+}
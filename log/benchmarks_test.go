@@ -1,8 +1,10 @@
 package log
 
 import (
+	"context"
 	"io/ioutil"
 	stdlog "log"
+	"runtime/pprof"
 	"testing"
 )
 
@@ -116,3 +118,37 @@ func BenchmarkParallelMinDEBUGok(b *testing.B) {
 		}
 	})
 }
+
+// Cost of recording goroutine info. Compare with BenchmarkGoroutineInfoOff
+func BenchmarkGoroutineInfoOn(b *testing.B) {
+	const testString = "test"
+	h := NewStdFormatter(ioutil.Discard, "", Llevel|Lgoroutine|Llabels)
+	l := NewLogger(LvlDEFAULT, h)
+	l.DoGoroutineInfo(true)
+	for i := 0; i < b.N; i++ {
+		l.INFO(testString)
+	}
+}
+
+// With goroutine info disabled, the flags cost nothing.
+func BenchmarkGoroutineInfoOff(b *testing.B) {
+	const testString = "test"
+	h := NewStdFormatter(ioutil.Discard, "", Llevel|Lgoroutine|Llabels)
+	l := NewLogger(LvlDEFAULT, h)
+	for i := 0; i < b.N; i++ {
+		l.INFO(testString)
+	}
+}
+
+// Getting a Logger with the pprof labels of a context
+func BenchmarkGoroutineLabels(b *testing.B) {
+	const testString = "test"
+	h := NewStdFormatter(ioutil.Discard, "", Llevel|Lgoroutine|Llabels)
+	l := NewLogger(LvlDEFAULT, h)
+	l.DoGoroutineInfo(true)
+	pprof.Do(context.Background(), pprof.Labels("job", "bench"), func(ctx context.Context) {
+		for i := 0; i < b.N; i++ {
+			l.ForContext(ctx).INFO(testString)
+		}
+	})
+}
@@ -0,0 +1,140 @@
+package log
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// Category is a named log category, like "sql" or "cache".
+// Events can be tagged with a category by logging through a Logger returned by In().
+// Each Logger can enable/disable categories to control which tagged events are
+// generated - in addition to the log level.
+// Categories are bits in the Logger config, so checking whether an event
+// should be generated is still a single atomic load.
+type Category uint32
+
+// Categories use the upper 16 bits of the Logger config.
+const (
+	categoryShift         = 16
+	maxCategories         = 16
+	maskCategories uint32 = 0xffff0000
+)
+
+var (
+	catmu    sync.Mutex   // serializing registration
+	catnames atomic.Value // *[maxCategories]string - copy on write
+)
+
+func init() {
+	catnames.Store(new([maxCategories]string))
+}
+
+// RegisterCategory returns the Category with the given name, registering
+// it if needed. At most 16 categories can be registered. If no more categories
+// are available the zero Category is returned, which tags nothing.
+// Categories are enabled on all Loggers until disabled.
+func RegisterCategory(name string) Category {
+	if c, ok := LookupCategory(name); ok {
+		return c
+	}
+	catmu.Lock()
+	defer catmu.Unlock()
+	old := catnames.Load().(*[maxCategories]string)
+	for i, n := range old {
+		if n == name {
+			return Category(1 << (categoryShift + uint(i)))
+		}
+		if n == "" {
+			names := *old
+			names[i] = name
+			catnames.Store(&names)
+			return Category(1 << (categoryShift + uint(i)))
+		}
+	}
+	return 0
+}
+
+// LookupCategory returns the Category registered with the given name.
+func LookupCategory(name string) (Category, bool) {
+	for i, n := range catnames.Load().(*[maxCategories]string) {
+		if n == name && n != "" {
+			return Category(1 << (categoryShift + uint(i))), true
+		}
+	}
+	return 0, false
+}
+
+// String returns the name of the Category.
+func (c Category) String() string {
+	names := catnames.Load().(*[maxCategories]string)
+	for i := range names {
+		if uint32(c) == 1<<(categoryShift+uint(i)) {
+			return names[i]
+		}
+	}
+	return ""
+}
+
+func categoryMask(cats []Category) (mask uint32) {
+	for _, c := range cats {
+		mask |= uint32(c)
+	}
+	return mask & maskCategories
+}
+
+// In returns a child Logger tagging all events with the named category
+// (registering it if needed). The child only generates events if the category
+// is enabled on the Logger.
+// Keep the returned Logger around instead of calling In() for every log statement
+//
+//	sqllog := l.In("sql")
+//	sqllog.DEBUG("query", "sql", q)
+func (l *Logger) In(name string) *Logger {
+	return l.InCategory(RegisterCategory(name))
+}
+
+// InCategory is like In(), but with an already registered Category
+func (l *Logger) InCategory(c Category) *Logger {
+	new := l.child()
+	new.cat = uint32(c) & maskCategories
+	return new
+}
+
+// Category returns the category events from the Logger are tagged with (or 0).
+func (l *Logger) Category() Category {
+	return Category(l.cat)
+}
+
+// EnableCategories enables generating events tagged with the categories.
+// Returns success.
+func (l *Logger) EnableCategories(cats ...Category) bool {
+	c := atomic.LoadUint32(&l.cfg.config)
+	n := c | categoryMask(cats)
+	return atomic.CompareAndSwapUint32(&l.cfg.config, c, n)
+}
+
+// DisableCategories disables generating events tagged with the categories.
+// Returns success.
+func (l *Logger) DisableCategories(cats ...Category) bool {
+	c := atomic.LoadUint32(&l.cfg.config)
+	n := c & ^categoryMask(cats)
+	return atomic.CompareAndSwapUint32(&l.cfg.config, c, n)
+}
+
+// SetCategories enables exactly the given categories, disabling all others.
+// Returns success.
+func (l *Logger) SetCategories(cats ...Category) bool {
+	c := atomic.LoadUint32(&l.cfg.config)
+	n := (c & ^maskCategories) | categoryMask(cats)
+	return atomic.CompareAndSwapUint32(&l.cfg.config, c, n)
+}
+
+// DoingCategory returns whether the category is enabled on the Logger.
+func (l *Logger) DoingCategory(c Category) bool {
+	return atomic.LoadUint32(&l.cfg.config)&uint32(c) != 0
+}
+
+// Create a child of the default Logger tagging events with the named category.
+func In(name string) *Logger {
+	return defaultLogger.In(name)
+}
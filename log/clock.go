@@ -0,0 +1,112 @@
+package log
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Clock provides the timestamps for log events.
+// Replace the Clock of a Logger (or all Loggers) to get deterministic timestamps
+// in tests, or to replay events with their original time.
+type Clock interface {
+	Now() time.Time
+}
+
+// ClockFunc makes a Clock out of a function by calling it on Now()
+type ClockFunc func() time.Time
+
+func (f ClockFunc) Now() time.Time {
+	return f()
+}
+
+// The system clock
+type sysClock struct{}
+
+func (sysClock) Now() time.Time {
+	return time.Now()
+}
+
+// atomic.Value needs a consistent concrete type
+type clockHolder struct {
+	Clock
+}
+
+// The clock used by all Loggers without their own Clock.
+var hierarchyClock atomic.Value
+
+func init() {
+	hierarchyClock.Store(clockHolder{sysClock{}})
+}
+
+// SetClock sets the Clock used by all Loggers which don't have their own Clock
+// set by Logger.SetClock(). Setting nil restores the system clock.
+func SetClock(c Clock) {
+	if c == nil {
+		c = sysClock{}
+	}
+	hierarchyClock.Store(clockHolder{c})
+}
+
+// SetClock sets the Clock used by the Logger (and all its With() children) to
+// timestamp events. Setting nil makes the Logger use the hierarchy Clock (see log.SetClock)
+func (l *Logger) SetClock(c Clock) {
+	l.cfg.clock.Store(clockHolder{c})
+}
+
+// Clock returns the Clock currently used by the Logger.
+func (l *Logger) Clock() Clock {
+	return l.cfg.getClock()
+}
+
+func (lc *lconfig) getClock() Clock {
+	if h, ok := lc.clock.Load().(clockHolder); ok && h.Clock != nil {
+		return h.Clock
+	}
+	return hierarchyClock.Load().(clockHolder).Clock
+}
+
+//---
+
+// FakeClock is a Clock for tests. It returns the same time until
+// told otherwise, optionally advancing a fixed step on every call to Now()
+type FakeClock struct {
+	mu   sync.Mutex
+	now  time.Time
+	step time.Duration
+}
+
+// NewFakeClock returns a FakeClock starting at t.
+func NewFakeClock(t time.Time) *FakeClock {
+	return &FakeClock{now: t}
+}
+
+// Now returns the fake time and advances the clock by any step set.
+func (c *FakeClock) Now() (t time.Time) {
+	c.mu.Lock()
+	t = c.now
+	c.now = c.now.Add(c.step)
+	c.mu.Unlock()
+	return
+}
+
+// Set sets the fake time.
+func (c *FakeClock) Set(t time.Time) {
+	c.mu.Lock()
+	c.now = t
+	c.mu.Unlock()
+}
+
+// Add advances the fake time by d.
+func (c *FakeClock) Add(d time.Duration) {
+	c.mu.Lock()
+	c.now = c.now.Add(d)
+	c.mu.Unlock()
+}
+
+// Step makes the clock advance by d after every call to Now().
+func (c *FakeClock) Step(d time.Duration) {
+	c.mu.Lock()
+	c.step = d
+	c.mu.Unlock()
+}
@@ -4,7 +4,6 @@ import (
 	"fmt"
 	"github.com/One-com/gonelog/syslog"
 	"io"
-	"os"
 )
 
 // New() will instantiate a logger with the same functionality (and limitations) as the std lib logger.
@@ -25,7 +24,7 @@ func (l *Logger) Fatal(v ...interface{}) {
 	lvl := syslog.LOG_ALERT
 	s := fmt.Sprint(v...)
 	l.log(lvl, s)
-	os.Exit(1)
+	exit()
 }
 
 // Fatalf() compatible with the standard lib logger
@@ -33,7 +32,7 @@ func (l *Logger) Fatalf(format string, v ...interface{}) {
 	lvl := syslog.LOG_ALERT
 	s := fmt.Sprintf(format, v...)
 	l.log(lvl, s)
-	os.Exit(1)
+	exit()
 }
 
 // Fatalln() compatible with the standard lib logger
@@ -41,7 +40,7 @@ func (l *Logger) Fatalln(v ...interface{}) {
 	lvl := syslog.LOG_ALERT
 	s := fmt.Sprintln(v...)
 	l.log(lvl, s)
-	os.Exit(1)
+	exit()
 }
 
 // Panic() compatible with the standard lib logger
@@ -49,7 +48,7 @@ func (l *Logger) Panic(v ...interface{}) {
 	lvl := syslog.LOG_ALERT
 	s := fmt.Sprint(v...)
 	l.log(lvl, s)
-	panic(s)
+	panic(panicLogged(s))
 }
 
 // Panicf() compatible with the standard lib logger
@@ -57,7 +56,7 @@ func (l *Logger) Panicf(format string, v ...interface{}) {
 	lvl := syslog.LOG_ALERT
 	s := fmt.Sprintf(format, v...)
 	l.log(lvl, s)
-	panic(s)
+	panic(panicLogged(s))
 }
 
 // Panicln compatible with the standard lib logger
@@ -65,7 +64,7 @@ func (l *Logger) Panicln(v ...interface{}) {
 	lvl := syslog.LOG_ALERT
 	s := fmt.Sprintln(v...)
 	l.log(lvl, s)
-	panic(s)
+	panic(panicLogged(s))
 }
 
 // Print() compatible with the standard lib logger
@@ -115,7 +114,7 @@ func (l *Logger) SetFlags(flag int) {
 	if flag&(Ldate|Ltime|Lmicroseconds) != 0 {
 		l.DoTime(true)
 	}
-	if flag&(Llongfile|Lshortfile) != 0 {
+	if flag&(Llongfile|Lshortfile|Lfunc|Lpackage) != 0 {
 		l.DoCodeInfo(true)
 	}
 
@@ -125,7 +124,7 @@ func (l *Logger) SetFlags(flag int) {
 	if flag&(Ldate|Ltime|Lmicroseconds) == 0 {
 		l.DoTime(false)
 	}
-	if flag&(Llongfile|Lshortfile) == 0 {
+	if flag&(Llongfile|Lshortfile|Lfunc|Lpackage) == 0 {
 		l.DoCodeInfo(false)
 	}
 }
@@ -56,6 +56,10 @@ Logging with key/value data is (in its most simple form) done by calling level s
 	log.CRIT("HI! ")
 	log.ALERT("HEY THERE, WAKE UP!")
 
+Below DEBUG there's an additional TRACE level (log.LvlTRACE) for very chatty logging, like protocol dumps. More levels (log.ExtLevel(9) to log.ExtLevel(15)) can be registered with RegisterLevel(). Outputs only knowing the 8 syslog levels will log them with the syslog level they are registered to map to (DEBUG for TRACE):
+
+	log.TRACE("packet", "data", pkt)
+
 Earch *log.Logger object has a current "log level" which determines the maximum log level for which events are actually generated. Logging above that level will be ignored. This log level can be controlled:
 
       log.SetLevel(syslog.LOG_WARN)
@@ -64,6 +68,7 @@ Earch *log.Logger object has a current "log level" which determines the maximum
 
 
 Calling Fatal*() and Panic*() will in addition to Fataling/panicing log at level ALERT.
+Recover() knows panics from Panic*() have already been logged.
 
 The Print*() methods will log events with a configurable "default" log level - which default to INFO.
 
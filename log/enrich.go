@@ -0,0 +1,264 @@
+package log
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"sync"
+)
+
+type enrichHandler struct {
+	h          Handler
+	static     []interface{} // fields computed once
+	lazy       bool          // static fields have Lazy values
+	goroutines bool
+}
+
+// EnrichOption adds fields to an EnrichHandler
+type EnrichOption func(*enrichHandler)
+
+// EnrichHostname adds the hostname as "host".
+func EnrichHostname() EnrichOption {
+	return func(h *enrichHandler) {
+		if host := procInfo().host; host != "" {
+			h.static = append(h.static, "host", host)
+		}
+	}
+}
+
+// EnrichPid adds the process ID as "pid".
+func EnrichPid() EnrichOption {
+	return func(h *enrichHandler) {
+		h.static = append(h.static, "pid", pid)
+	}
+}
+
+// EnrichExecutable adds the name of the executable as "exe".
+func EnrichExecutable() EnrichOption {
+	return func(h *enrichHandler) {
+		if exe := procInfo().exe; exe != "" {
+			h.static = append(h.static, "exe", exe)
+		}
+	}
+}
+
+// EnrichBuildInfo adds a "build" group with the module "version", the VCS "revision"
+// and "modified" (if the working tree had changes) from the build info of the binary.
+func EnrichBuildInfo() EnrichOption {
+	return func(h *enrichHandler) {
+		if kv := procInfo().build; kv != nil {
+			h.static = append(h.static, KVGroup{Name: "build", KV: kv}, nil)
+		}
+	}
+}
+
+// EnrichContainer adds a "container" group with the container "id" from /proc/self/cgroup,
+// and when running in Kubernetes a "k8s" group with "pod", "namespace" and "node"
+// from the environment variables POD_NAME (or HOSTNAME), POD_NAMESPACE (or the service
+// account namespace file) and NODE_NAME.
+func EnrichContainer() EnrichOption {
+	return func(h *enrichHandler) {
+		info := procInfo()
+		if info.container != "" {
+			h.static = append(h.static, KVGroup{Name: "container", KV: []interface{}{"id", info.container}}, nil)
+		}
+		if info.k8s != nil {
+			h.static = append(h.static, KVGroup{Name: "k8s", KV: info.k8s}, nil)
+		}
+	}
+}
+
+// EnrichGoroutines adds the current number of goroutines as "goroutines" to each event.
+func EnrichGoroutines() EnrichOption {
+	return func(h *enrichHandler) {
+		h.goroutines = true
+	}
+}
+
+// EnrichKV adds fixed key/value data.
+func EnrichKV(kv ...interface{}) EnrichOption {
+	return func(h *enrichHandler) {
+		h.static = append(h.static, normalize(kv)...)
+	}
+}
+
+// ParseEnrich returns the options for a comma separated list of field names
+// (like from a config file): "host", "pid", "exe", "build", "container" and "goroutines".
+func ParseEnrich(spec string) (opts []EnrichOption, err error) {
+	for _, name := range strings.Split(spec, ",") {
+		switch strings.TrimSpace(name) {
+		case "":
+		case "host":
+			opts = append(opts, EnrichHostname())
+		case "pid":
+			opts = append(opts, EnrichPid())
+		case "exe":
+			opts = append(opts, EnrichExecutable())
+		case "build":
+			opts = append(opts, EnrichBuildInfo())
+		case "container":
+			opts = append(opts, EnrichContainer())
+		case "goroutines":
+			opts = append(opts, EnrichGoroutines())
+		default:
+			return nil, fmt.Errorf("unknown enrich field %q", name)
+		}
+	}
+	return
+}
+
+// EnrichHandler adds fields about the host and process to events before passing them
+// on to h. The fields go before the event data, so event data with the same keys
+// take precedence (see DupPolicyOpt()).
+// The process information is read once, and shared by all EnrichHandlers.
+//
+//	h = log.EnrichHandler(h, log.EnrichHostname(), log.EnrichPid(), log.EnrichBuildInfo())
+func EnrichHandler(h Handler, options ...EnrichOption) Handler {
+	e := &enrichHandler{h: h}
+	for _, option := range options {
+		option(e)
+	}
+	e.lazy = needsResolve(e.static)
+	return e
+}
+
+func (h *enrichHandler) Chained() []interface{} {
+	return []interface{}{h.h}
+}
+
+func (h *enrichHandler) Log(e Event) error {
+	static := h.static
+	if h.lazy {
+		static = resolveData(static, 0)
+	}
+	n := len(h.static) + len(e.Data)
+	if h.goroutines {
+		n += 2
+	}
+	// Keep the resolved data of the event, so Lazy values are only evaluated once
+	// even if the event is also passed to other Handlers.
+	resolved := e.ResolvedData()
+	data := make([]interface{}, 0, n)
+	res := make([]interface{}, 0, len(static)+len(resolved)+2)
+	data = append(data, h.static...)
+	res = append(res, static...)
+	if h.goroutines {
+		g := runtime.NumGoroutine()
+		data = append(data, "goroutines", g)
+		res = append(res, "goroutines", g)
+	}
+	data = append(data, e.Data...)
+	res = append(res, resolved...)
+	ne := *e.event
+	ne.Data = data
+	ne.rok, ne.resolved = true, res
+	return h.h.Log(Event{&ne})
+}
+
+//---
+
+type processInfo struct {
+	host      string
+	exe       string
+	build     []interface{}
+	container string
+	k8s       []interface{}
+}
+
+var (
+	procOnce sync.Once
+	proc     processInfo
+)
+
+func procInfo() *processInfo {
+	procOnce.Do(func() {
+		proc.host, _ = os.Hostname()
+		if exe, err := os.Executable(); err == nil {
+			proc.exe = filepath.Base(exe)
+		} else {
+			proc.exe = filepath.Base(os.Args[0])
+		}
+		proc.build = buildInfo()
+		if f, err := os.Open("/proc/self/cgroup"); err == nil {
+			proc.container = containerID(f)
+			f.Close()
+		}
+		proc.k8s = k8sInfo()
+	})
+	return &proc
+}
+
+func buildInfo() (kv []interface{}) {
+	bi, ok := debug.ReadBuildInfo()
+	if !ok {
+		return nil
+	}
+	kv = append(kv, "version", bi.Main.Version)
+	for _, s := range bi.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			kv = append(kv, "revision", s.Value)
+		case "vcs.modified":
+			if s.Value == "true" {
+				kv = append(kv, "modified", true)
+			}
+		}
+	}
+	return kv
+}
+
+// containerID finds the 64 hex digit container ID in the cgroup paths, like
+// "0::/system.slice/docker-<id>.scope" or "4:cpu:/kubepods/burstable/pod.../<id>"
+func containerID(r io.Reader) string {
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		line := s.Text()
+		run := 0
+		for i := len(line) - 1; i >= -1; i-- {
+			if i >= 0 && isHexDigit(line[i]) {
+				run++
+				continue
+			}
+			if run == 64 {
+				return line[i+1 : i+65]
+			}
+			run = 0
+		}
+	}
+	return ""
+}
+
+func isHexDigit(c byte) bool {
+	return (c >= '0' && c <= '9') || (c >= 'a' && c <= 'f')
+}
+
+func k8sInfo() (kv []interface{}) {
+	if os.Getenv("KUBERNETES_SERVICE_HOST") == "" {
+		return nil
+	}
+	pod := os.Getenv("POD_NAME")
+	if pod == "" {
+		pod = os.Getenv("HOSTNAME")
+	}
+	if pod != "" {
+		kv = append(kv, "pod", pod)
+	}
+	ns := os.Getenv("POD_NAMESPACE")
+	if ns == "" {
+		if b, err := os.ReadFile("/var/run/secrets/kubernetes.io/serviceaccount/namespace"); err == nil {
+			ns = strings.TrimSpace(string(b))
+		}
+	}
+	if ns != "" {
+		kv = append(kv, "namespace", ns)
+	}
+	if node := os.Getenv("NODE_NAME"); node != "" {
+		kv = append(kv, "node", node)
+	}
+	return kv
+}
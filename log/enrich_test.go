@@ -0,0 +1,74 @@
+package log
+
+import (
+	"bytes"
+	"github.com/One-com/gonelog/syslog"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestEnrichHandler(t *testing.T) {
+	var buf bytes.Buffer
+	opts, err := ParseEnrich("pid, goroutines")
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts = append(opts, EnrichKV("app", "test"))
+	l := NewLogger(syslog.LOG_INFO, EnrichHandler(NewStdFormatter(&buf, "", 0), opts...))
+	l.INFO("hello", "app", "override")
+
+	out := buf.String()
+	if !strings.HasPrefix(out, "hello pid=") || !strings.Contains(out, " goroutines=") || !strings.HasSuffix(out, " app=override\n") {
+		t.Errorf("wrong output: %q", out)
+	}
+	if _, err := ParseEnrich("host,bogus"); err == nil {
+		t.Error("unknown field accepted")
+	}
+}
+
+func TestContainerID(t *testing.T) {
+	id := strings.Repeat("0123456789abcdef", 4)
+	tests := map[string]string{
+		"0::/system.slice/docker-" + id + ".scope\n":                   id,
+		"12:cpu,cpuacct:/kubepods/burstable/pod1234-5678/" + id + "\n": id,
+		"11:memory:/user.slice\n0::/init.scope\n":                      "",
+		"1:name=systemd:/docker/" + id + "ff\n":                        "",
+	}
+	for cgroup, want := range tests {
+		if got := containerID(strings.NewReader(cgroup)); got != want {
+			t.Errorf("%q: got %q", cgroup, got)
+		}
+	}
+}
+
+func TestK8sInfo(t *testing.T) {
+	for k, v := range map[string]string{"KUBERNETES_SERVICE_HOST": "10.0.0.1", "POD_NAME": "web-1", "POD_NAMESPACE": "prod", "NODE_NAME": "n1"} {
+		old, had := os.LookupEnv(k)
+		os.Setenv(k, v)
+		if had {
+			defer os.Setenv(k, old)
+		} else {
+			defer os.Unsetenv(k)
+		}
+	}
+	kv := k8sInfo()
+	if len(kv) != 6 || kv[1] != "web-1" || kv[3] != "prod" || kv[5] != "n1" {
+		t.Errorf("wrong k8s info: %v", kv)
+	}
+}
+
+func TestEnrichLazyOnce(t *testing.T) {
+	var js, std bytes.Buffer
+	l := NewLogger(syslog.LOG_INFO, MultiHandler(
+		NewJSONFormatter(&js),
+		EnrichHandler(NewStdFormatter(&std, "", 0), EnrichKV("app", "test"))))
+	calls := 0
+	l.INFO("lazy", "x", Lazy(func() interface{} { calls++; return calls }))
+	if calls != 1 {
+		t.Errorf("Lazy evaluated %d times", calls)
+	}
+	if !strings.Contains(js.String(), `"x":1`) || std.String() != "lazy app=test x=1\n" {
+		t.Errorf("wrong output: %q %q", js.String(), std.String())
+	}
+}
@@ -0,0 +1,138 @@
+package log
+
+import (
+	"fmt"
+	"github.com/One-com/gonelog/syslog"
+	"os"
+	"strings"
+)
+
+// EnvVar is the environment variable read at startup to configure Logger levels.
+// See SetLevelSpec() for the syntax.
+const EnvVar = "GONELOG"
+
+// A parsed entry of a level spec
+type levelSpec struct {
+	pattern []string // Logger name pattern in segments. nil for the default Logger
+	level   syslog.Priority
+	time    bool // turn on DoTime()
+	code    bool // turn on DoCodeInfo()
+}
+
+// SetLevelSpec configures Logger levels from a comma separated spec like:
+//
+//	info,mylib/db=debug,net/*=warn:time,code
+//
+// A bare level sets the level of the default Logger and of all named Loggers.
+// A name=level entry sets the level for Loggers matching the name pattern
+// (see RouteName for the glob syntax), which are applied after the bare levels
+// in the order given.
+// A level can be followed by ":" and flags "time" and/or "code" (separated by
+// ":" or ",") turning on DoTime()/DoCodeInfo() for the Loggers.
+// Levels are parsed by ParseLevel ("trace", "debug", "warning", "err", "7", ...)
+//
+// The spec applies to existing Loggers as well as Loggers created later by GetLogger().
+// It replaces any earlier spec (but doesn't undo the levels it set).
+// Invalid entries are skipped and reported in the returned error.
+// The spec in the GONELOG environment variable is applied at startup.
+func SetLevelSpec(spec string) error {
+	specs, err := parseLevelSpec(spec)
+	man.setSpecs(specs)
+	return err
+}
+
+func configureFromEnv() {
+	if spec := os.Getenv(EnvVar); spec != "" {
+		if err := SetLevelSpec(spec); err != nil {
+			defaultLogger.ERROR("Bad "+EnvVar+" environment variable", "err", err)
+		}
+	}
+}
+
+func parseLevelSpec(spec string) (specs []levelSpec, err error) {
+	var bad []string
+	var bare, named []levelSpec
+	var last *levelSpec // the last entry having flags - to add more flags to
+
+	for _, tok := range strings.Split(spec, ",") {
+		tok = strings.TrimSpace(tok)
+		if tok == "" {
+			continue
+		}
+		var name string
+		lvlstr := tok
+		hasname := false
+		if i := strings.IndexByte(tok, '='); i >= 0 {
+			name, lvlstr, hasname = tok[:i], tok[i+1:], true
+		}
+		parts := strings.Split(lvlstr, ":")
+
+		lvl, perr := ParseLevel(parts[0])
+		if perr != nil {
+			// maybe a continuation of the flags of the previous entry
+			if !hasname && last != nil && parseSpecFlags(last, parts) {
+				continue
+			}
+			bad = append(bad, tok)
+			last = nil
+			continue
+		}
+		s := levelSpec{level: lvl}
+		if !parseSpecFlags(&s, parts[1:]) {
+			bad = append(bad, tok)
+			last = nil
+			continue
+		}
+		if hasname {
+			s.pattern = strings.Split(name, "/")
+			named = append(named, s)
+			last = &named[len(named)-1]
+		} else {
+			bare = append(bare, s)
+			last = &bare[len(bare)-1]
+		}
+		if len(parts) == 1 {
+			last = nil
+		}
+	}
+	specs = append(bare, named...)
+	if bad != nil {
+		err = fmt.Errorf("invalid level spec entries: %s", strings.Join(bad, ", "))
+	}
+	return
+}
+
+// parseSpecFlags sets the flags on s, returning false if a flag is unknown.
+func parseSpecFlags(s *levelSpec, flags []string) bool {
+	for _, f := range flags {
+		switch strings.ToLower(strings.TrimSpace(f)) {
+		case "time":
+			s.time = true
+		case "code":
+			s.code = true
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// apply sets the level and flags of the Logger. Retrying the CAS operations
+// since the Logger might be in use.
+func (s *levelSpec) apply(l *Logger) {
+	for !l.SetLevel(s.level) {
+	}
+	if s.time {
+		for !l.DoTime(true) {
+		}
+	}
+	if s.code {
+		for !l.DoCodeInfo(true) {
+		}
+	}
+}
+
+// matches returns whether the spec applies to the named Logger
+func (s *levelSpec) matches(l *Logger) bool {
+	return s.pattern == nil || matchSegments(s.pattern, l.name)
+}
@@ -0,0 +1,58 @@
+package log
+
+import (
+	"github.com/One-com/gonelog/syslog"
+	"testing"
+)
+
+func TestParseLevelSpec(t *testing.T) {
+	specs, err := parseLevelSpec("mylib/db=debug, info ,net/*=warn:time,code,x=3:code,bogus,y=7:nope")
+	if err == nil || err.Error() != "invalid level spec entries: bogus, y=7:nope" {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if len(specs) != 4 {
+		t.Fatalf("expected 4 specs, got %d: %v", len(specs), specs)
+	}
+	if specs[0].pattern != nil || specs[0].level != syslog.LOG_INFO {
+		t.Errorf("bare level not first: %v", specs[0])
+	}
+	if s := specs[2]; s.level != syslog.LOG_WARN || !s.time || !s.code {
+		t.Errorf("bad net/* spec: %v", s)
+	}
+	if s := specs[3]; s.level != syslog.LOG_ERR || s.time || !s.code {
+		t.Errorf("bad x spec: %v", s)
+	}
+}
+
+func TestSetLevelSpec(t *testing.T) {
+	before := GetLogger("envtest/db")
+	lvl, doTime, doCode := before.Level(), before.DoingTime(), before.DoingCodeInfo()
+	man.mu.Lock()
+	specs := man.specs
+	man.mu.Unlock()
+	err := SetLevelSpec("envtest/db=debug,envtest/net/*=warn:time,code")
+	defer func() {
+		for _, name := range []string{"envtest/db", "envtest/net/http"} {
+			l := GetLogger(name)
+			l.SetLevel(lvl)
+			l.DoTime(doTime)
+			l.DoCodeInfo(doCode)
+		}
+		man.setSpecs(specs)
+	}()
+	if err != nil {
+		t.Fatal(err)
+	}
+	after := GetLogger("envtest/net/http")
+	other := GetLogger("envtest/other")
+
+	if before.Level() != syslog.LOG_DEBUG {
+		t.Errorf("existing logger level %d", before.Level())
+	}
+	if after.Level() != syslog.LOG_WARN || !after.DoingTime() || !after.DoingCodeInfo() {
+		t.Errorf("new logger not configured: %d", after.Level())
+	}
+	if other.Level() != LvlDEFAULT {
+		t.Errorf("unrelated logger level %d", other.Level())
+	}
+}
@@ -0,0 +1,159 @@
+package log
+
+import (
+	"io"
+	"strings"
+	"sync/atomic"
+)
+
+// ErrorHandler is called when a Handler fails to log an event, with the failing Handler,
+// the event and the error.
+// The Event is only valid during the call. Don't log to the failing Logger from an
+// ErrorHandler.
+type ErrorHandler func(h Handler, e Event, err error)
+
+// HandlerError is the error of a Handler, passed on by Handlers which distribute events
+// to several Handlers (like MultiHandler), to report which Handler failed.
+type HandlerError struct {
+	Handler Handler
+	Err     error
+}
+
+func (e *HandlerError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *HandlerError) Unwrap() error {
+	return e.Err
+}
+
+// MultiError holds the errors (as *HandlerError) of the failing Handlers of a MultiHandler.
+type MultiError struct {
+	Errors []error
+	Logged bool // Other Handlers logged the event
+}
+
+func (e *MultiError) Error() string {
+	s := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		s[i] = err.Error()
+	}
+	return strings.Join(s, "; ")
+}
+
+func (e *MultiError) Unwrap() []error {
+	return e.Errors
+}
+
+// wrapHandlerError tags the error of h with the Handler, unless it already tells
+// which Handler(s) failed.
+func wrapHandlerError(h Handler, err error) error {
+	switch err.(type) {
+	case *HandlerError, *MultiError:
+		return err
+	}
+	return &HandlerError{Handler: h, Err: err}
+}
+
+// partlyLogged tells whether the event was logged despite the error.
+func partlyLogged(err error) bool {
+	m, ok := err.(*MultiError)
+	return ok && m.Logged
+}
+
+// eachHandlerError calls fn with each failing Handler and its error.
+func eachHandlerError(h Handler, err error, fn func(h Handler, err error)) {
+	switch x := err.(type) {
+	case *HandlerError:
+		eachHandlerError(x.Handler, x.Err, fn)
+	case *MultiError:
+		for _, err := range x.Errors {
+			eachHandlerError(h, err, fn)
+		}
+	default:
+		fn(h, err)
+	}
+}
+
+// SetErrorHandler sets a function to be called when a Handler fails to log an event
+// from this Logger, or from any Logger below it in the name hierarchy without its own
+// ErrorHandler. nil removes it.
+func (l *Logger) SetErrorHandler(fn ErrorHandler) {
+	l.h.SwapErrorHandler(fn)
+}
+
+//---
+
+// Stats counts what happened to the events logged by a Logger.
+type Stats struct {
+	Handled uint64 // Events logged by a Handler (maybe only some of a MultiHandler)
+	Failed  uint64 // Errors from Handlers
+	Dropped uint64 // Events not logged by any Handler or the last resort Writer
+}
+
+type counters struct {
+	handled uint64
+	failed  uint64
+	dropped uint64
+}
+
+// Stats returns the event counts of the Logger. Loggers share the counts
+// with their context children (from With() etc.).
+func (l *Logger) Stats() Stats {
+	c := &l.h.stats
+	return Stats{
+		Handled: atomic.LoadUint64(&c.handled),
+		Failed:  atomic.LoadUint64(&c.failed),
+		Dropped: atomic.LoadUint64(&c.dropped),
+	}
+}
+
+// LoggerStats returns the event counts of all Loggers in the name hierarchy by name.
+// The default Logger (the root) has the name "".
+func LoggerStats() map[string]Stats {
+	ls := man.loggers()
+	stats := make(map[string]Stats, len(ls))
+	for _, l := range ls {
+		stats[l.name] = l.Stats()
+	}
+	return stats
+}
+
+//---
+
+type lastResortHolder struct {
+	h Handler
+}
+
+var lastResort atomic.Value
+
+func init() {
+	lastResort.Store(lastResortHolder{})
+}
+
+// SetLastResort sets a Writer (typically os.Stderr) to write events to, when no
+// Handler could log them. The events are formatted with LstdFlags|Llevel|Lname
+// and the error as "log_error".
+// nil disables it (the default).
+func SetLastResort(w io.Writer) {
+	var h Handler
+	if w != nil {
+		h = NewStdFormatter(SyncWriter(w), "", LstdFlags|Llevel|Lname)
+	}
+	lastResort.Store(lastResortHolder{h: h})
+}
+
+// logLastResort logs the event to any last resort Writer, returning whether it did.
+func logLastResort(e *event, err error) bool {
+	h := lastResort.Load().(lastResortHolder).h
+	if h == nil {
+		return false
+	}
+	// Use the resolved data, not to evaluate Lazy values again
+	resolved := Event{e}.ResolvedData()
+	ne := *e
+	ne.Data = make([]interface{}, 0, len(resolved)+2)
+	ne.Data = append(append(ne.Data, resolved...), "log_error", err.Error())
+	ne.rok, ne.resolved = true, ne.Data
+	return h.Log(Event{&ne}) == nil
+}
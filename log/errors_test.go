@@ -0,0 +1,130 @@
+package log
+
+import (
+	"bytes"
+	"errors"
+	"github.com/One-com/gonelog/syslog"
+	"strings"
+	"testing"
+)
+
+func TestErrorHandler(t *testing.T) {
+	errA, errB := errors.New("a failed"), errors.New("b failed")
+	var ok bytes.Buffer
+	a := HandlerFunc(func(e Event) error { return errA })
+	b := HandlerFunc(func(e Event) error { return errB })
+	c := NewMinFormatter(&ok)
+
+	type report struct {
+		h   Handler
+		msg string
+		err error
+	}
+	var reports []report
+	l := NewLogger(syslog.LOG_INFO, MultiHandler(a, MultiHandler(b, c)))
+	l.SetErrorHandler(func(h Handler, e Event, err error) {
+		reports = append(reports, report{h, e.Msg, err})
+	})
+
+	err := l.With("k", "v").Log(syslog.LOG_INFO, "hello")
+	if len(reports) != 2 {
+		t.Fatalf("expected 2 reports, got %d", len(reports))
+	}
+	if reports[0].msg != "hello" || reports[0].err != errA || reports[1].err != errB {
+		t.Errorf("wrong reports: %v", reports)
+	}
+	// Handlers are only comparable through the error
+	if _, ok := reports[0].h.(handlerFunc); !ok {
+		t.Errorf("wrong Handler reported: %T", reports[0].h)
+	}
+	if !errors.Is(err, errA) || !errors.Is(err, errB) || err.Error() != "a failed; b failed" {
+		t.Errorf("wrong error: %v", err)
+	}
+	if ok.String() != "<6>hello k=v\n" {
+		t.Errorf("working Handler not used: %q", ok.String())
+	}
+
+	s := l.Stats()
+	if s.Handled != 1 || s.Failed != 2 || s.Dropped != 0 {
+		t.Errorf("wrong stats: %+v", s)
+	}
+}
+
+func TestHierarchyErrors(t *testing.T) {
+	var reported []string
+	root := newLogger("")
+	var buf bytes.Buffer
+	root.SetHandler(NewMinFormatter(&buf))
+	root.SetErrorHandler(func(h Handler, e Event, err error) {
+		reported = append(reported, e.Name+": "+err.Error())
+	})
+	p := newLogger("p")
+	p.h.SwapParent(root)
+	p.SetHandler(HandlerFunc(func(e Event) error { return errors.New("broken") }))
+
+	p.INFO("falls back")
+	if len(reported) != 1 || reported[0] != "p: broken" {
+		t.Errorf("wrong reports: %v", reported)
+	}
+	if buf.String() != "<6>falls back\n" {
+		t.Errorf("parent didn't log: %q", buf.String())
+	}
+	if s := p.Stats(); s.Handled != 1 || s.Failed != 1 || s.Dropped != 0 {
+		t.Errorf("wrong stats: %+v", s)
+	}
+
+	// No fall back when some Handler logged the event
+	var pbuf bytes.Buffer
+	p.SetHandler(MultiHandler(NewMinFormatter(&pbuf), HandlerFunc(func(e Event) error { return errors.New("broken") })))
+	buf.Reset()
+	p.INFO("partly logged")
+	if pbuf.String() != "<6>partly logged\n" || buf.Len() != 0 {
+		t.Errorf("wrong output: %q %q", pbuf.String(), buf.String())
+	}
+	if s := p.Stats(); s.Handled != 2 || s.Failed != 2 || s.Dropped != 0 {
+		t.Errorf("wrong stats: %+v", s)
+	}
+}
+
+func TestLastResort(t *testing.T) {
+	var buf bytes.Buffer
+	SetLastResort(&buf)
+	defer SetLastResort(nil)
+
+	calls := 0
+	lazy := Lazy(func() interface{} { calls++; return calls })
+	l := NewLogger(syslog.LOG_INFO, HandlerFunc(func(e Event) error {
+		e.ResolvedData()
+		return errors.New("broken")
+	}))
+	if err := l.Log(syslog.LOG_WARN, "nowhere", "k", lazy); err == nil || calls != 1 {
+		t.Errorf("wrong error: %v, Lazy evaluated %d times", err, calls)
+	}
+	if !strings.HasSuffix(buf.String(), " nowhere k=1 log_error=broken\n") {
+		t.Errorf("wrong last resort output: %q", buf.String())
+	}
+	buf.Reset()
+
+	l = NewLogger(syslog.LOG_INFO, nil)
+	if err := l.Log(syslog.LOG_WARN, "nowhere", "k", 1); err != ErrNotLogged {
+		t.Errorf("wrong error: %v", err)
+	}
+	out := buf.String()
+	if !strings.HasPrefix(out, "<4> () ") || !strings.HasSuffix(out, " nowhere k=1 log_error=\"No handler found to log event\"\n") {
+		t.Errorf("wrong last resort output: %q", out)
+	}
+	if s := l.Stats(); s.Dropped != 0 {
+		t.Errorf("last resort event dropped: %+v", s)
+	}
+}
+
+func TestLoggerStats(t *testing.T) {
+	l := GetLogger("statstest")
+	l.SetHandler(HandlerFunc(func(e Event) error { return nil }))
+	before := l.Stats()
+	l.INFO("one")
+	l.With("k", 1).INFO("two")
+	if s := LoggerStats()["statstest"]; s.Handled != before.Handled+2 {
+		t.Errorf("wrong stats: %+v", s)
+	}
+}
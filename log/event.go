@@ -10,6 +10,7 @@ import (
 // Event is the basic log event type.
 // Exported to be able to implement Handler interface for external packages.
 // Handlers passed an Event "e" can access e.Lvl, e.Msg, e.Data, e.Name
+// (or e.ResolvedData() to have Lazy values evaluated)
 type Event struct {
 	*event
 }
@@ -46,44 +47,80 @@ type event struct {
 	Name string          // Name of the logger generating this event.
 
 	// Time is only evaluated if needed
-	tok  bool
-	time time.Time
+	tok   bool
+	time  time.Time
+	clock Clock // to timestamp the event later if needed
 
-	// So is file/line/stack information
+	// So is file/line/function information
 	fok  bool
 	file string
 	line int
+	fn   string // fully qualified function name
+
+	cat uint32 // category bit
+
+	fac syslog.Priority // syslog facility, 0 for none
+
+	// goroutine info, if recorded
+	goid   uint64
+	labels []string
+
+	// Data with Lazy values evaluated, once needed
+	rok      bool
+	resolved []interface{}
 }
 
 // keynames for fixed event fields, when needed (such as in JSON)
 type EventKeyNames struct {
-	Lvl  string
-	Name string
-	Time string
-	Msg  string
-	File string
-	Line string
+	Lvl    string
+	Name   string
+	Time   string
+	Msg    string
+	File   string
+	Line   string
+	Func   string
+	Cat    string
+	Goid   string
+	Labels string
+	Fac    string
 }
 
 var defaultKeyNames = &EventKeyNames{
-	Lvl:  "_lvl",
-	Name: "_name",
-	Time: "_ts",
-	Msg:  "_msg",
-	File: "_file",
-	Line: "_line",
+	Lvl:    "_lvl",
+	Name:   "_name",
+	Time:   "_ts",
+	Msg:    "_msg",
+	File:   "_file",
+	Line:   "_line",
+	Func:   "_func",
+	Cat:    "_cat",
+	Goid:   "_goid",
+	Labels: "_labels",
+	Fac:    "_facility",
 }
 
 // Time returns the timestamp of an event.
+// If the Logger didn't timestamp the event on creation (DoTime()), it's
+// timestamped on the first call to Time() and will keep that timestamp.
+// Handlers are called in sequence on the logging go-routine and the event
+// is not used after the Logger is done with it, so this is race free.
 func (e *event) Time() (t time.Time) {
-	if e.tok {
-		return e.time
-	} else {
-		// don't modify an event after creation
-		// If you need a single timestamp for all usages of the event,
-		// enable DoTime() in the Logger
-		return time.Now() // get some timestamp
+	if !e.tok {
+		if e.clock != nil {
+			e.time = e.clock.Now()
+		} else {
+			e.time = time.Now()
+		}
+		e.tok = true
 	}
+	return e.time
+}
+
+// Facility returns the syslog facility of the event (syslog.LOG_LOCAL0, ...) from the
+// priority it was logged with or from Logger.WithFacility(). 0 if none.
+// The facility is not part of e.Lvl.
+func (e Event) Facility() syslog.Priority {
+	return e.fac
 }
 
 // FileInfo returns the file and line number of a log event.
@@ -91,47 +128,90 @@ func (e Event) FileInfo() (string, int) {
 	return e.file, e.line
 }
 
+// Category returns the name of any category the event was tagged with.
+func (e Event) Category() string {
+	if e.cat == 0 {
+		return ""
+	}
+	return Category(e.cat).String()
+}
+
+// FuncInfo returns the fully qualified name of the function which logged the event.
+// Like "github.com/One-com/gonelog/log.(*Logger).INFO"
+func (e Event) FuncInfo() string {
+	return e.fn
+}
+
+// PackageInfo returns the package import path and the package local function name
+// of the function which logged the event.
+func (e Event) PackageInfo() (pkg string, fn string) {
+	return splitFuncName(e.fn)
+}
+
+// splitFuncName splits a fully qualified function name as returned by
+// runtime.Frame.Function into package import path and function name.
+func splitFuncName(name string) (pkg string, fn string) {
+	// Dots in the last element of the package path are escaped by the
+	// runtime (gopkg.in/pkg%2ev1), so the first dot after the last slash
+	// ends the package path.
+	i := 0
+	for j := len(name) - 1; j >= 0; j-- {
+		if name[j] == '/' {
+			i = j + 1
+			break
+		}
+	}
+	for j := i; j < len(name); j++ {
+		if name[j] == '.' {
+			return name[:j], name[j+1:]
+		}
+	}
+	return "", name
+}
+
+// caller records file, line and function of the stack frame skip frames
+// above the caller of caller().
+func (e *event) caller(skip int) {
+	var pcs [1]uintptr
+	// +2 to skip runtime.Callers and caller() itself.
+	if runtime.Callers(skip+2, pcs[:]) < 1 {
+		return
+	}
+	frame, _ := runtime.CallersFrames(pcs[:]).Next()
+	if frame.PC == 0 {
+		return
+	}
+	e.file = frame.File
+	e.line = frame.Line
+	e.fn = frame.Function
+	e.fok = true
+}
+
 // To support stdlib Output() function which gives user control of calldepth.
 func (l *Logger) calldepthEvent(level syslog.Priority, calldepth int, msg string) *event {
 
-	e := getPoolEvent(level, l.name, msg)
+	e := getPoolEvent(severity(level), l.name, msg)
+	e.cat = l.cat
+	if e.fac = level.Facility(); e.fac == 0 {
+		e.fac = l.fac
+	}
 
-	dt, dc := l.cfg.doing()
+	e.clock = l.cfg.getClock()
+	dt, dc, dg := l.cfg.doing()
+	dt, dc = dt || l.do&maskDoTime != 0, dc || l.do&maskDoCode != 0
 	if dt {
-		e.time = time.Now()
+		e.time = e.clock.Now()
 		e.tok = true
 	}
 	if dc {
-		_, file, line, ok := runtime.Caller(calldepth + 2)
-		if ok {
-			e.file = file
-			e.line = line
-			e.fok = true
-		}
+		e.caller(calldepth + 2 + l.skip)
 	}
-
-	if l.cparent == nil && l.data != nil {
-		// Traverse contexts gather KV data
-		var i int
-		// tally up the kv length
-		parent := l
-		for parent != nil {
-			i += len(parent.data)
-			parent = parent.cparent
-		}
-		newdata := make([]interface{}, i)
-		// Now collect data
-		parent = l
-		i = 0
-		for parent != nil {
-			for _, k_or_v := range parent.data {
-				newdata[i] = k_or_v
-				i++
-			}
-			parent = parent.cparent
-		}
-		e.Data = newdata
+	if dg {
+		e.goid = goroutineID()
+		e.labels = l.labels
 	}
+
+	e.Data = l.contextData(nil)
 	return e
 }
 
@@ -141,50 +221,42 @@ func (l *Logger) calldepthEvent(level syslog.Priority, calldepth int, msg string
 // KV data is gathered from any context parents.
 func (l *Logger) newEvent(level syslog.Priority, msg string, data []interface{}) *event {
 
-	e := getPoolEvent(level, l.name, msg)
+	e := getPoolEvent(severity(level), l.name, msg)
+	e.cat = l.cat
+	if e.fac = level.Facility(); e.fac == 0 {
+		e.fac = l.fac
+	}
 
-	dt, dc := l.cfg.doing()
+	e.clock = l.cfg.getClock()
+	dt, dc, dg := l.cfg.doing()
+	dt, dc = dt || l.do&maskDoTime != 0, dc || l.do&maskDoCode != 0
 	if dt {
-		e.time = time.Now()
+		e.time = e.clock.Now()
 		e.tok = true
 	}
 	if dc {
-		_, file, line, ok := runtime.Caller(3)
-		if ok {
-			e.file = file
-			e.line = line
-			e.fok = true
-		}
+		e.caller(3 + l.skip)
 	}
-
-	if l.cparent == nil && l.data == nil {
-		e.Data = data
-	} else {
-		// Traverse contexts gather KV data
-		var i int
-		// tally up the kv length
-		parent := l
-		for parent != nil {
-			i += len(parent.data)
-			parent = parent.cparent
-		}
-		newdata := make([]interface{}, i+len(data))
-		// Now collect data
-		parent = l
-		i = 0
-		for parent != nil {
-			for _, k_or_v := range parent.data {
-				newdata[i] = k_or_v
-				i++
-			}
-			parent = parent.cparent
-		}
-		// add the event specific kv data
-		for _, k_or_v := range data {
-			newdata[i] = k_or_v
-			i++
-		}
-		e.Data = newdata
+	if dg {
+		e.goid = goroutineID()
+		e.labels = l.labels
 	}
+
+	e.Data = l.contextData(data)
 	return e
 }
+
+// contextData gathers the K/V data of the Logger (including that of its context parents),
+// followed by the event specific data (in the groups of the Logger).
+func (l *Logger) contextData(data []interface{}) []interface{} {
+	if l.groups != nil && data != nil {
+		data = inGroups(l.groups, data)
+	}
+	if l.data == nil {
+		return data
+	}
+	newdata := make([]interface{}, 0, len(l.data)+len(data))
+	newdata = append(newdata, l.data...)
+	// add the event specific kv data
+	return append(newdata, data...)
+}
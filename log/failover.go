@@ -0,0 +1,288 @@
+package log
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Name of the Logger the FailoverWriter reports transitions to.
+// Like any named Logger, it logs to the default Logger unless configured otherwise.
+const FailoverLoggerName = "gonelog/failover"
+
+// Defaults for new FailoverWriters
+var (
+	// FailoverTimeout is the time a Write may take before the Writer is considered failing.
+	// 0 for no timeout.
+	FailoverTimeout = 5 * time.Second
+	// FailoverMinBackoff is the time before the primary Writer is probed the first time
+	FailoverMinBackoff = 100 * time.Millisecond
+	// FailoverMaxBackoff is the maximum time between probes of the primary Writer
+	FailoverMaxBackoff = time.Minute
+)
+
+// ErrWriteTimeout is returned by a FailoverWriter when a Write takes longer than FailoverTimeout.
+var ErrWriteTimeout = errors.New("log: write timeout")
+
+// errWriterBusy is returned for Writers still doing a Write which timed out.
+var errWriterBusy = errors.New("log: writer busy with a timed out write")
+
+// Prober is implemented by Writers which can tell whether they work, without writing.
+type Prober interface {
+	Probe() error
+}
+
+type deadliner interface {
+	SetWriteDeadline(t time.Time) error
+}
+
+type failoverOutput struct {
+	w    io.Writer
+	name string // for reporting
+	busy int32  // a Write (maybe timed out) is in progress
+}
+
+type failoverWriter struct {
+	mu      sync.Mutex
+	outs    []*failoverOutput // primary first
+	cur     int               // index of the Writer in use
+	down    time.Time         // when the primary failed, zero if it works
+	err     error             // why the primary failed
+	up      time.Time         // when the primary recovered
+	backoff time.Duration
+	probe   *time.Timer // the pending probe, if any
+	closed  bool
+
+	timeout    time.Duration
+	minBackoff time.Duration
+	maxBackoff time.Duration
+}
+
+// FailoverWriter creates an EvWriter writing to the primary Writer until a Write fails
+// or times out (see FailoverTimeout). It then writes to the first secondary Writer which works,
+// while the primary is probed in the background with exponential backoff.
+// If the primary is a Prober, Probe() is called, else an empty Write is done.
+// When the probe succeeds, the primary is used again.
+// While the primary is down, events go to the secondary Writers only.
+// Writes are serialized, and with a timeout done on another goroutine, with a copy of the data.
+// A Writer still busy with a timed out Write is considered failing.
+// Failing over and recovering is reported by events on the FailoverLoggerName Logger.
+// Close() stops the probing. It doesn't close the Writers.
+func FailoverWriter(primary io.Writer, secondary ...io.Writer) EvWriter {
+	w := &failoverWriter{
+		timeout:    FailoverTimeout,
+		minBackoff: FailoverMinBackoff,
+		maxBackoff: FailoverMaxBackoff,
+	}
+	for i, out := range append([]io.Writer{primary}, secondary...) {
+		w.outs = append(w.outs, &failoverOutput{w: out, name: writerName(out, i)})
+	}
+	return w
+}
+
+// writerName describes a Writer for the transition events
+func writerName(w io.Writer, i int) string {
+	switch x := w.(type) {
+	case fmt.Stringer:
+		return x.String()
+	case net.Conn:
+		return x.RemoteAddr().Network() + "://" + x.RemoteAddr().String()
+	case interface{ Name() string }: // *os.File
+		return x.Name()
+	}
+	return fmt.Sprintf("%d:%T", i, w)
+}
+
+func (w *failoverWriter) Write(b []byte) (n int, err error) {
+	return w.EvWrite(Event{nil}, b)
+}
+
+func (w *failoverWriter) EvWrite(e Event, b []byte) (n int, err error) {
+	var from, to *failoverOutput
+	var failed error
+
+	w.mu.Lock()
+	start := w.cur
+	if start == 0 && !w.down.IsZero() {
+		start = 1 // the primary is being probed
+		failed = w.err
+	}
+	for i := start; i < len(w.outs); i++ {
+		n, err = w.write(w.outs[i], e, b)
+		if err == nil {
+			if i != w.cur {
+				from, to = w.outs[w.cur], w.outs[i]
+				w.cur = i
+			}
+			if i == 0 && w.backoff != 0 && time.Since(w.up) > w.backoff {
+				w.backoff = 0 // stable again
+			}
+			break
+		}
+		failed = err
+		if i == 0 {
+			w.primaryFailed(err)
+		}
+	}
+	w.mu.Unlock()
+	if start == len(w.outs) {
+		return 0, failed
+	}
+
+	// Report after releasing the lock. The event might end up here.
+	if to != nil {
+		failoverLogger().WARN("log writer failed, failing over", "from", from.name, "to", to.name, "error", failed)
+	}
+	return
+}
+
+// write writes to a Writer, enforcing the timeout.
+func (w *failoverWriter) write(o *failoverOutput, e Event, b []byte) (n int, err error) {
+	if w.timeout <= 0 {
+		return writeEvent(o.w, e, b)
+	}
+	if d, ok := o.w.(deadliner); ok && d.SetWriteDeadline(time.Now().Add(w.timeout)) == nil {
+		if !atomic.CompareAndSwapInt32(&o.busy, 0, 1) {
+			return 0, errWriterBusy
+		}
+		n, err = writeEvent(o.w, e, b)
+		d.SetWriteDeadline(time.Time{})
+		atomic.StoreInt32(&o.busy, 0)
+		return
+	}
+	// The Write might outlive the event and buffer of the caller
+	var ce Event
+	if e.event != nil {
+		ne := *e.event
+		ce = Event{&ne}
+	}
+	cb := append([]byte(nil), b...)
+	return w.timed(o, func() (int, error) { return writeEvent(o.w, ce, cb) })
+}
+
+type writeResult struct {
+	n   int
+	err error
+}
+
+// timed runs fn (doing I/O on o) on another goroutine, waiting at most the timeout for it.
+func (w *failoverWriter) timed(o *failoverOutput, fn func() (int, error)) (int, error) {
+	if !atomic.CompareAndSwapInt32(&o.busy, 0, 1) {
+		return 0, errWriterBusy
+	}
+	done := make(chan writeResult, 1)
+	go func() {
+		n, err := fn()
+		atomic.StoreInt32(&o.busy, 0)
+		done <- writeResult{n, err}
+	}()
+	t := time.NewTimer(w.timeout)
+	defer t.Stop()
+	select {
+	case r := <-done:
+		return r.n, r.err
+	case <-t.C:
+		return 0, ErrWriteTimeout
+	}
+}
+
+// writeEvent writes to out, with the event if there is one and out is an EvWriter.
+// EvWriters may write less than b (like when filtering), other Writers may not.
+func writeEvent(out io.Writer, e Event, b []byte) (n int, err error) {
+	if ew, ok := out.(EvWriter); ok && e.event != nil {
+		return ew.EvWrite(e, b)
+	}
+	n, err = out.Write(b)
+	if err == nil && n != len(b) {
+		err = io.ErrShortWrite
+	}
+	return
+}
+
+// primaryFailed marks the primary Writer down and schedules probing it, backing off if it
+// has failed recently.
+// must be called with the lock held.
+func (w *failoverWriter) primaryFailed(err error) {
+	w.down, w.err = time.Now(), err
+	if w.backoff == 0 {
+		w.backoff = w.minBackoff
+	} else {
+		w.backoff *= 2
+	}
+	w.schedule()
+}
+
+// schedule schedules the next probe, replacing any pending probe.
+// must be called with the lock held.
+func (w *failoverWriter) schedule() {
+	if w.backoff > w.maxBackoff {
+		w.backoff = w.maxBackoff
+	}
+	if w.probe != nil {
+		w.probe.Stop()
+		w.probe = nil
+	}
+	if !w.closed {
+		w.probe = time.AfterFunc(w.backoff, w.doProbe)
+	}
+}
+
+// doProbe probes the primary Writer in the background.
+// Writers which aren't Probers get an empty Write (without an event).
+func (w *failoverWriter) doProbe() {
+	primary := w.outs[0]
+	var err error
+	if p, ok := primary.w.(Prober); ok {
+		_, err = w.timed(primary, func() (int, error) { return 0, p.Probe() })
+	} else {
+		_, err = w.write(primary, Event{nil}, nil)
+	}
+
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return
+	}
+	if err != nil {
+		w.err = err
+		w.backoff *= 2
+		w.schedule()
+		w.mu.Unlock()
+		return
+	}
+	from := w.outs[w.cur]
+	down := time.Since(w.down)
+	w.cur = 0
+	w.down, w.err, w.probe = time.Time{}, nil, nil
+	w.up = time.Now()
+	w.mu.Unlock()
+
+	failoverLogger().NOTICE("log writer recovered", "from", from.name, "to", primary.name, "down", down)
+}
+
+// Close stops probing the primary Writer.
+func (w *failoverWriter) Close() error {
+	w.mu.Lock()
+	w.closed = true
+	if w.probe != nil {
+		w.probe.Stop()
+	}
+	w.mu.Unlock()
+	return nil
+}
+
+func (w *failoverWriter) Chained() []interface{} {
+	c := make([]interface{}, len(w.outs))
+	for i, o := range w.outs {
+		c[i] = o.w
+	}
+	return c
+}
+
+func failoverLogger() *Logger {
+	return GetLogger(FailoverLoggerName)
+}
@@ -0,0 +1,253 @@
+package log
+
+import (
+	"bytes"
+	"errors"
+	"github.com/One-com/gonelog/syslog"
+	"io"
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type flakyWriter struct {
+	mu    sync.Mutex
+	buf   bytes.Buffer
+	fail  bool
+	tries int
+}
+
+func (f *flakyWriter) Write(b []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.tries++
+	if f.fail {
+		return 0, errors.New("flaky")
+	}
+	return f.buf.Write(b)
+}
+
+func (f *flakyWriter) setFail(fail bool) {
+	f.mu.Lock()
+	f.fail = fail
+	f.mu.Unlock()
+}
+
+func (f *flakyWriter) output() string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.buf.String()
+}
+
+// waitEvents waits for the recorder to have n events.
+func waitEvents(t *testing.T, rec *eventRecorder, n int) []Event {
+	for start := time.Now(); time.Since(start) < time.Second; time.Sleep(time.Millisecond) {
+		rec.mu.Lock()
+		events := append([]Event(nil), rec.events...)
+		rec.mu.Unlock()
+		if len(events) >= n {
+			return events
+		}
+	}
+	t.Fatalf("timeout waiting for %d events", n)
+	return nil
+}
+
+func TestFailoverWriter(t *testing.T) {
+	defer func(d time.Duration) { FailoverMinBackoff = d }(FailoverMinBackoff)
+	FailoverMinBackoff = 10 * time.Millisecond
+	rec := &eventRecorder{}
+	fl := GetLogger(FailoverLoggerName)
+	fl.SetHandler(rec)
+	defer fl.SetHandler(nil)
+
+	primary, secondary := &flakyWriter{}, &flakyWriter{}
+	w := FailoverWriter(primary, secondary)
+	defer w.(io.Closer).Close()
+	l := NewLogger(syslog.LOG_INFO, NewMinFormatter(w))
+
+	l.INFO("one")
+	primary.setFail(true)
+	l.INFO("two")
+	l.INFO("three")
+	if len(rec.events) != 1 || rec.events[0].Lvl != syslog.LOG_WARN || rec.events[0].Msg != "log writer failed, failing over" {
+		t.Fatalf("no failover event")
+	}
+	if kv := rec.data[0]; kv[1] != "0:*log.flakyWriter" || kv[3] != "1:*log.flakyWriter" || kv[5].(error).Error() != "flaky" {
+		t.Errorf("wrong failover event data: %v", kv)
+	}
+
+	primary.setFail(false)
+	events := waitEvents(t, rec, 2)
+	if events[1].Lvl != syslog.LOG_NOTICE || events[1].Msg != "log writer recovered" {
+		t.Errorf("wrong recover event: %v", events[1].Msg)
+	}
+	l.INFO("four")
+	if primary.output() != "<6>one\n<6>four\n" || secondary.output() != "<6>two\n<6>three\n" {
+		t.Errorf("wrong output: %q, %q", primary.output(), secondary.output())
+	}
+}
+
+type hangingWriter struct {
+	release chan struct{}
+}
+
+func (h *hangingWriter) Write(b []byte) (int, error) {
+	<-h.release
+	return len(b), nil
+}
+
+func TestFailoverTimeout(t *testing.T) {
+	defer func(d time.Duration) { FailoverTimeout = d }(FailoverTimeout)
+	FailoverTimeout = 10 * time.Millisecond
+	rec := &eventRecorder{}
+	GetLogger(FailoverLoggerName).SetHandler(rec)
+	defer GetLogger(FailoverLoggerName).SetHandler(nil)
+
+	hanging := &hangingWriter{release: make(chan struct{})}
+	var secondary bytes.Buffer
+	w := FailoverWriter(hanging, &secondary)
+	defer w.(io.Closer).Close()
+	if _, err := w.Write([]byte("timeout\n")); err != nil {
+		t.Fatal(err)
+	}
+	if secondary.String() != "timeout\n" {
+		t.Errorf("not written to secondary: %q", secondary.String())
+	}
+	if len(rec.data) != 1 || rec.data[0][5] != ErrWriteTimeout {
+		t.Errorf("timeout not reported")
+	}
+	close(hanging.release)
+}
+
+type deadlineWriter struct {
+	bytes.Buffer
+	deadlines []time.Time
+}
+
+func (d *deadlineWriter) SetWriteDeadline(t time.Time) error {
+	d.deadlines = append(d.deadlines, t)
+	return nil
+}
+
+func TestFailoverDeadline(t *testing.T) {
+	d := &deadlineWriter{}
+	w := FailoverWriter(d)
+	w.Write([]byte("x"))
+	if len(d.deadlines) != 2 || d.deadlines[0].IsZero() || !d.deadlines[1].IsZero() {
+		t.Errorf("deadline not set and reset: %v", d.deadlines)
+	}
+
+	// net.Conn deadlines work
+	stuck, other := net.Pipe() // nobody reads
+	defer stuck.Close()
+	defer other.Close()
+	defer func(d time.Duration) { FailoverTimeout = d }(FailoverTimeout)
+	FailoverTimeout = 10 * time.Millisecond
+	GetLogger(FailoverLoggerName).SetHandler(HandlerFunc(func(e Event) error { return nil }))
+	defer GetLogger(FailoverLoggerName).SetHandler(nil)
+	var secondary bytes.Buffer
+	w = FailoverWriter(stuck, &secondary)
+	defer w.(io.Closer).Close()
+	if _, err := w.Write([]byte("timeout\n")); err != nil || secondary.String() != "timeout\n" {
+		t.Errorf("not written to secondary: %v %q", err, secondary.String())
+	}
+}
+
+func TestFailoverClose(t *testing.T) {
+	defer func(d time.Duration) { FailoverMinBackoff = d }(FailoverMinBackoff)
+	FailoverMinBackoff = time.Millisecond
+	GetLogger(FailoverLoggerName).SetHandler(HandlerFunc(func(e Event) error { return nil }))
+	defer GetLogger(FailoverLoggerName).SetHandler(nil)
+
+	primary := &flakyWriter{fail: true}
+	w := FailoverWriter(primary, &flakyWriter{})
+	w.Write([]byte("x"))
+	w.(io.Closer).Close()
+	time.Sleep(5 * time.Millisecond)
+	primary.mu.Lock()
+	tries := primary.tries
+	primary.mu.Unlock()
+	time.Sleep(20 * time.Millisecond)
+	primary.mu.Lock()
+	defer primary.mu.Unlock()
+	if primary.tries != tries {
+		t.Errorf("still probing after Close")
+	}
+}
+
+type failingProber struct {
+	flakyWriter
+	probes int32
+}
+
+func (f *failingProber) Probe() error {
+	atomic.AddInt32(&f.probes, 1)
+	return errors.New("still down")
+}
+
+func TestFailoverAllFailing(t *testing.T) {
+	defer func(d time.Duration) { FailoverMinBackoff = d }(FailoverMinBackoff)
+	defer func(d time.Duration) { FailoverMaxBackoff = d }(FailoverMaxBackoff)
+	FailoverMinBackoff, FailoverMaxBackoff = 5*time.Millisecond, 20*time.Millisecond
+	GetLogger(FailoverLoggerName).SetHandler(HandlerFunc(func(e Event) error { return nil }))
+	defer GetLogger(FailoverLoggerName).SetHandler(nil)
+
+	primary := &failingProber{flakyWriter: flakyWriter{fail: true}}
+	w := FailoverWriter(primary, &flakyWriter{fail: true})
+	for i := 0; i < 50; i++ {
+		if _, err := w.Write([]byte("x")); err == nil {
+			t.Fatal("no error")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	// One chain of probes: 5, 10, 20, 20, ... ms
+	time.Sleep(50 * time.Millisecond)
+	if n := atomic.LoadInt32(&primary.probes); n < 2 || n > 12 {
+		t.Errorf("%d probes", n)
+	}
+	if primary.tries != 1 {
+		t.Errorf("primary written %d times while down", primary.tries)
+	}
+	w.(io.Closer).Close()
+	n := atomic.LoadInt32(&primary.probes)
+	time.Sleep(50 * time.Millisecond)
+	if m := atomic.LoadInt32(&primary.probes); m != n {
+		t.Errorf("still probing after Close: %d probes, then %d", n, m)
+	}
+}
+
+// eventReader is an EvWriter needing the event
+type eventReader struct {
+	bytes.Buffer
+	levels []syslog.Priority
+}
+
+func (r *eventReader) EvWrite(e Event, b []byte) (int, error) {
+	r.levels = append(r.levels, e.Lvl)
+	return r.Write(b)
+}
+
+func TestFailoverEvWriters(t *testing.T) {
+	rec := &eventRecorder{}
+	GetLogger(FailoverLoggerName).SetHandler(rec)
+	defer GetLogger(FailoverLoggerName).SetHandler(nil)
+
+	var filtered bytes.Buffer
+	er := &eventReader{}
+	w := FailoverWriter(MultiEventWriter(LevelFilterWriter(syslog.LOG_WARN, &filtered), er), &flakyWriter{})
+	defer w.(io.Closer).Close()
+	l := NewLogger(syslog.LOG_INFO, NewMinFormatter(w))
+	l.INFO("filtered")
+	l.WARN("written")
+	w.Write([]byte("no event\n"))
+
+	if len(rec.events) != 0 {
+		t.Errorf("failed over: %v", rec.data)
+	}
+	if filtered.String() != "<4>written\nno event\n" || len(er.levels) != 2 || er.String() != "<6>filtered\n<4>written\nno event\n" {
+		t.Errorf("wrong output: %q %q %v", filtered.String(), er.String(), er.levels)
+	}
+}
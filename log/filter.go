@@ -0,0 +1,675 @@
+package log
+
+import (
+	"fmt"
+	"github.com/One-com/gonelog/syslog"
+	"strconv"
+	"strings"
+)
+
+// Filter is a compiled filter expression matching events, like:
+//
+//	lvl <= warn && name =~ "db/**" && kv.user_id == 42 && msg contains "timeout"
+//
+// Fields are:
+//
+//	lvl       the event level. Compared to levels by name (warn, "debug", ...) or number.
+//	          "lvl <= warn" matches WARN and more severe events.
+//	name      the Logger name
+//	msg       the event message
+//	cat       the event category
+//	kv.KEY    the value of KEY in the event key/value data. A dotted KEY looks in groups,
+//	          so "kv.http.method" is key "method" in group "http".
+//
+// Operators are ==, !=, <, <=, >, >= (levels and numbers), =~ and !~ (glob match,
+// where "*" matches any sequence of characters and "?" a single character - for
+// "name" with the segments semantics of RouteName()) and "contains".
+// Strings are double quoted with Go escapes. Numbers, true and false can be compared
+// with kv values. A bare kv.KEY is true if the event has the key. Comparing a
+// missing key is false, except for != and !~.
+// Comparisons are combined with &&, ||, ! and parentheses.
+//
+// Matching an event does not allocate, except for kv values needing String()/Error().
+// If a key in the expression has a Lazy value (or might be in a LazyKV), the event data
+// is resolved (see Event.ResolvedData()), so the values are still only evaluated once.
+// Use it with FilterHandler or FilterWriter:
+//
+//	f, err := log.CompileFilter(expr)
+//	...
+//	h = log.FilterHandler(f.Match, h)
+//
+// Filter implements encoding.TextUnmarshaler and flag.Value to be read from config files
+// and command lines.
+type Filter struct {
+	expr string
+	root filterNode
+}
+
+// FilterError is a filter expression syntax error.
+type FilterError struct {
+	Expr string
+	Pos  int // byte offset in Expr
+	Msg  string
+}
+
+func (e *FilterError) Error() string {
+	return fmt.Sprintf("filter: %s at position %d in %q", e.Msg, e.Pos, e.Expr)
+}
+
+// CompileFilter parses a filter expression.
+func CompileFilter(expr string) (*Filter, error) {
+	p := &filterParser{expr: expr}
+	p.next()
+	root := p.parseOr()
+	if p.err == nil && p.tok.kind != tokEOF {
+		p.fail(p.tok.pos, "unexpected %s", p.tok)
+	}
+	if p.err != nil {
+		return nil, p.err
+	}
+	return &Filter{expr: expr, root: root}, nil
+}
+
+// MustCompileFilter is like CompileFilter, but panics on errors.
+func MustCompileFilter(expr string) *Filter {
+	f, err := CompileFilter(expr)
+	if err != nil {
+		panic(err)
+	}
+	return f
+}
+
+// Match tells whether the event matches the filter. An empty Filter matches all events.
+func (f *Filter) Match(e Event) bool {
+	if f == nil || f.root == nil {
+		return true
+	}
+	return f.root.match(e)
+}
+
+// String returns the filter expression
+func (f *Filter) String() string {
+	if f == nil {
+		return ""
+	}
+	return f.expr
+}
+
+// Set compiles the expression into the Filter (for flag.Value)
+func (f *Filter) Set(expr string) error {
+	if strings.TrimSpace(expr) == "" {
+		*f = Filter{}
+		return nil
+	}
+	nf, err := CompileFilter(expr)
+	if err != nil {
+		return err
+	}
+	*f = *nf
+	return nil
+}
+
+// UnmarshalText compiles the expression into the Filter
+func (f *Filter) UnmarshalText(text []byte) error {
+	return f.Set(string(text))
+}
+
+// MarshalText returns the expression
+func (f *Filter) MarshalText() ([]byte, error) {
+	return []byte(f.String()), nil
+}
+
+//---
+// Evaluation
+
+type filterNode interface {
+	match(e Event) bool
+}
+
+type andNode struct{ a, b filterNode }
+type orNode struct{ a, b filterNode }
+type notNode struct{ a filterNode }
+
+func (n andNode) match(e Event) bool { return n.a.match(e) && n.b.match(e) }
+func (n orNode) match(e Event) bool  { return n.a.match(e) || n.b.match(e) }
+func (n notNode) match(e Event) bool { return !n.a.match(e) }
+
+type filterOp int
+
+const (
+	opEq filterOp = iota
+	opNe
+	opLt
+	opLe
+	opGt
+	opGe
+	opGlob
+	opNotGlob
+	opContains
+)
+
+var filterOps = map[string]filterOp{
+	"==": opEq, "!=": opNe, "<": opLt, "<=": opLe, ">": opGt, ">=": opGe,
+	"=~": opGlob, "!~": opNotGlob, "contains": opContains,
+}
+
+func (op filterOp) ordering() bool {
+	return op >= opLt && op <= opGe
+}
+
+// negated tells whether the op is true for a missing value
+func (op filterOp) negated() bool {
+	return op == opNe || op == opNotGlob
+}
+
+func compareOrdered(op filterOp, c int) bool {
+	switch op {
+	case opEq:
+		return c == 0
+	case opNe:
+		return c != 0
+	case opLt:
+		return c < 0
+	case opLe:
+		return c <= 0
+	case opGt:
+		return c > 0
+	case opGe:
+		return c >= 0
+	}
+	return false
+}
+
+func compareString(op filterOp, s, lit string, pattern []string) bool {
+	switch op {
+	case opEq:
+		return s == lit
+	case opNe:
+		return s != lit
+	case opGlob:
+		if pattern != nil {
+			return matchSegments(pattern, s)
+		}
+		return globMatch(lit, s)
+	case opNotGlob:
+		if pattern != nil {
+			return !matchSegments(pattern, s)
+		}
+		return !globMatch(lit, s)
+	case opContains:
+		return strings.Contains(s, lit)
+	}
+	return false
+}
+
+type levelNode struct {
+	op  filterOp
+	lvl syslog.Priority
+}
+
+func (n levelNode) match(e Event) bool {
+	c := 0
+	if e.Lvl < n.lvl {
+		c = -1
+	} else if e.Lvl > n.lvl {
+		c = 1
+	}
+	return compareOrdered(n.op, c)
+}
+
+const (
+	fieldName = iota
+	fieldMsg
+	fieldCat
+)
+
+type stringNode struct {
+	field   int
+	op      filterOp
+	lit     string
+	pattern []string // name segments for =~ on name
+}
+
+func (n stringNode) match(e Event) bool {
+	var s string
+	switch n.field {
+	case fieldName:
+		s = e.Name
+	case fieldMsg:
+		s = e.Msg
+	case fieldCat:
+		s = e.Category()
+	}
+	return compareString(n.op, s, n.lit, n.pattern)
+}
+
+type litKind int
+
+const (
+	litString litKind = iota
+	litNumber
+	litBool
+)
+
+type kvNode struct {
+	path   []string
+	exists bool // bare kv.KEY
+	op     filterOp
+	kind   litKind
+	str    string
+	num    float64
+	b      bool
+}
+
+func (n kvNode) match(e Event) bool {
+	var v interface{}
+	var ok, lazy bool
+	if e.rok {
+		v, ok, _ = lookupKV(e.resolved, n.path)
+	} else if v, ok, lazy = lookupKV(e.Data, n.path); lazy {
+		v, ok, _ = lookupKV(e.ResolvedData(), n.path)
+	}
+	if n.exists {
+		return ok
+	}
+	if !ok {
+		return n.op.negated()
+	}
+	switch n.kind {
+	case litNumber:
+		f, ok := numberValue(v)
+		if !ok {
+			return n.op == opNe
+		}
+		c := 0
+		if f < n.num {
+			c = -1
+		} else if f > n.num {
+			c = 1
+		}
+		return compareOrdered(n.op, c)
+	case litBool:
+		b, ok := v.(bool)
+		return (ok && b == n.b) == (n.op == opEq)
+	}
+	s, ok := stringValue(v)
+	if !ok {
+		return n.op.negated()
+	}
+	return compareString(n.op, s, n.str, nil)
+}
+
+// lookupKV finds the value of the key path, looking into groups.
+// It tells if the data needs to be resolved to find the value: If there's a Lazy
+// value on the path or a LazyKV or LogMarshaler (etc.) which might have it.
+func lookupKV(keyvals []interface{}, path []string) (v interface{}, ok bool, lazy bool) {
+	for i := 0; i+1 < len(keyvals); i += 2 {
+		switch k := keyvals[i].(type) {
+		case string:
+			if k != path[0] {
+				if len(path) > 1 && isPath(k, path) {
+					return keyvals[i+1], true, false
+				}
+				continue
+			}
+			v = keyvals[i+1]
+			if _, isLazy := v.(Lazy); isLazy || (len(path) > 1 && expandable(v)) {
+				return nil, false, true
+			}
+			if len(path) == 1 {
+				return v, true, false
+			}
+		case KVGroup:
+			if k.Name == path[0] && len(path) > 1 {
+				if v, ok, lazy = lookupKV(k.KV, path[1:]); ok || lazy {
+					return
+				}
+			}
+		case LazyKV:
+			return nil, false, true
+		}
+	}
+	return nil, false, false
+}
+
+// isPath tells whether the dotted key is the path, like the "err.causes" of an error.
+func isPath(key string, path []string) bool {
+	for i, p := range path {
+		if i > 0 {
+			if key == "" || key[0] != '.' {
+				return false
+			}
+			key = key[1:]
+		}
+		if !strings.HasPrefix(key, p) {
+			return false
+		}
+		key = key[len(p):]
+	}
+	return key == ""
+}
+
+func numberValue(v interface{}) (float64, bool) {
+	switch x := v.(type) {
+	case int:
+		return float64(x), true
+	case int8:
+		return float64(x), true
+	case int16:
+		return float64(x), true
+	case int32:
+		return float64(x), true
+	case int64:
+		return float64(x), true
+	case uint:
+		return float64(x), true
+	case uint8:
+		return float64(x), true
+	case uint16:
+		return float64(x), true
+	case uint32:
+		return float64(x), true
+	case uint64:
+		return float64(x), true
+	case float32:
+		return float64(x), true
+	case float64:
+		return x, true
+	case string:
+		f, err := strconv.ParseFloat(x, 64)
+		return f, err == nil
+	case fmt.Stringer:
+		f, err := strconv.ParseFloat(safeString(x), 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+func stringValue(v interface{}) (string, bool) {
+	switch x := v.(type) {
+	case string:
+		return x, true
+	case error:
+		s, ok := safeError(x).(string)
+		return s, ok
+	case fmt.Stringer:
+		return safeString(x), true
+	}
+	return "", false
+}
+
+//---
+// Parsing
+
+type tokKind int
+
+const (
+	tokEOF tokKind = iota
+	tokIdent
+	tokString
+	tokNumber
+	tokOp
+	tokAnd
+	tokOr
+	tokNot
+	tokLParen
+	tokRParen
+)
+
+type filterToken struct {
+	kind tokKind
+	pos  int
+	text string // identifier, operator or unquoted string
+}
+
+func (t filterToken) String() string {
+	switch t.kind {
+	case tokEOF:
+		return "end of expression"
+	case tokString:
+		return strconv.Quote(t.text)
+	}
+	return "\"" + t.text + "\""
+}
+
+type filterParser struct {
+	expr string
+	pos  int
+	tok  filterToken
+	err  *FilterError
+}
+
+func (p *filterParser) fail(pos int, format string, args ...interface{}) {
+	if p.err == nil {
+		p.err = &FilterError{Expr: p.expr, Pos: pos, Msg: fmt.Sprintf(format, args...)}
+	}
+	p.tok = filterToken{kind: tokEOF, pos: len(p.expr)}
+	p.pos = len(p.expr)
+}
+
+func isIdentChar(c byte) bool {
+	return c == '_' || c == '.' || c == '-' || c == '/' ||
+		(c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+// next reads the next token
+func (p *filterParser) next() {
+	s := p.expr
+	for p.pos < len(s) && (s[p.pos] == ' ' || s[p.pos] == '\t' || s[p.pos] == '\n') {
+		p.pos++
+	}
+	start := p.pos
+	if start >= len(s) {
+		p.tok = filterToken{kind: tokEOF, pos: start}
+		return
+	}
+	two := ""
+	if start+1 < len(s) {
+		two = s[start : start+2]
+	}
+	switch {
+	case two == "&&":
+		p.tok = filterToken{tokAnd, start, two}
+	case two == "||":
+		p.tok = filterToken{tokOr, start, two}
+	case two == "==" || two == "!=" || two == "<=" || two == ">=" || two == "=~" || two == "!~":
+		p.tok = filterToken{tokOp, start, two}
+	case s[start] == '<' || s[start] == '>':
+		p.tok = filterToken{tokOp, start, s[start : start+1]}
+	case s[start] == '!':
+		p.tok = filterToken{tokNot, start, "!"}
+	case s[start] == '(':
+		p.tok = filterToken{tokLParen, start, "("}
+	case s[start] == ')':
+		p.tok = filterToken{tokRParen, start, ")"}
+	case s[start] == '"':
+		q, err := strconv.QuotedPrefix(s[start:])
+		if err != nil {
+			p.fail(start, "unterminated or invalid string")
+			return
+		}
+		u, _ := strconv.Unquote(q)
+		p.tok = filterToken{tokString, start, u}
+		p.pos += len(q)
+		return
+	case s[start] == '-' || (s[start] >= '0' && s[start] <= '9'):
+		end := start + 1
+		for end < len(s) && (s[end] == '.' || (s[end] >= '0' && s[end] <= '9')) {
+			end++
+		}
+		p.tok = filterToken{tokNumber, start, s[start:end]}
+		p.pos = end
+		return
+	case isIdentChar(s[start]):
+		end := start
+		for end < len(s) && isIdentChar(s[end]) {
+			end++
+		}
+		p.tok = filterToken{tokIdent, start, s[start:end]}
+		if p.tok.text == "contains" {
+			p.tok.kind = tokOp
+		}
+		p.pos = end
+		return
+	default:
+		p.fail(start, "unexpected character %q", s[start])
+		return
+	}
+	p.pos += len(p.tok.text)
+}
+
+func (p *filterParser) parseOr() filterNode {
+	n := p.parseAnd()
+	for p.tok.kind == tokOr {
+		p.next()
+		n = orNode{n, p.parseAnd()}
+	}
+	return n
+}
+
+func (p *filterParser) parseAnd() filterNode {
+	n := p.parseUnary()
+	for p.tok.kind == tokAnd {
+		p.next()
+		n = andNode{n, p.parseUnary()}
+	}
+	return n
+}
+
+func (p *filterParser) parseUnary() filterNode {
+	switch p.tok.kind {
+	case tokNot:
+		p.next()
+		return notNode{p.parseUnary()}
+	case tokLParen:
+		open := p.tok.pos
+		p.next()
+		n := p.parseOr()
+		if p.tok.kind != tokRParen {
+			if p.err == nil {
+				p.fail(p.tok.pos, "missing \")\" for \"(\" at position %d", open)
+			}
+			return nil
+		}
+		p.next()
+		return n
+	case tokIdent:
+		return p.parseComparison()
+	}
+	p.fail(p.tok.pos, "expected field, \"!\" or \"(\", got %s", p.tok)
+	return nil
+}
+
+func (p *filterParser) parseComparison() filterNode {
+	field := p.tok
+	p.next()
+
+	if strings.HasPrefix(field.text, "kv.") {
+		path := strings.Split(field.text[3:], ".")
+		for _, seg := range path {
+			if seg == "" {
+				p.fail(field.pos, "invalid key %q", field.text)
+				return nil
+			}
+		}
+		if p.tok.kind != tokOp {
+			return kvNode{path: path, exists: true}
+		}
+		op := filterOps[p.tok.text]
+		p.next()
+		n := kvNode{path: path, op: op}
+		lit := p.tok
+		switch {
+		case lit.kind == tokString:
+			n.kind, n.str = litString, lit.text
+		case lit.kind == tokNumber:
+			f, err := strconv.ParseFloat(lit.text, 64)
+			if err != nil {
+				p.fail(lit.pos, "invalid number %s", lit)
+				return nil
+			}
+			n.kind, n.num = litNumber, f
+		case lit.kind == tokIdent && (lit.text == "true" || lit.text == "false"):
+			n.kind, n.b = litBool, lit.text == "true"
+		default:
+			p.fail(lit.pos, "expected string, number, true or false, got %s", lit)
+			return nil
+		}
+		if n.kind != litNumber && op.ordering() {
+			p.fail(lit.pos, "operator %q needs a number", opName(op))
+			return nil
+		}
+		if n.kind == litBool && op != opEq && op != opNe {
+			p.fail(lit.pos, "operator %q can't be used with %s", opName(op), lit)
+			return nil
+		}
+		if n.kind == litNumber && !op.ordering() && op != opEq && op != opNe {
+			p.fail(lit.pos, "operator %q needs a string", opName(op))
+			return nil
+		}
+		p.next()
+		return n
+	}
+
+	if p.tok.kind != tokOp {
+		p.fail(p.tok.pos, "expected operator after %q, got %s", field.text, p.tok)
+		return nil
+	}
+	opTok := p.tok
+	op := filterOps[opTok.text]
+	p.next()
+	lit := p.tok
+
+	switch field.text {
+	case "lvl", "level":
+		if lit.kind != tokIdent && lit.kind != tokString && lit.kind != tokNumber {
+			p.fail(lit.pos, "expected level, got %s", lit)
+			return nil
+		}
+		if !op.ordering() && op != opEq && op != opNe {
+			p.fail(opTok.pos, "operator %q can't be used with levels", opTok.text)
+			return nil
+		}
+		lvl, err := ParseLevel(lit.text)
+		if err != nil {
+			p.fail(lit.pos, "unknown level %s", lit)
+			return nil
+		}
+		p.next()
+		return levelNode{op: op, lvl: lvl}
+	case "name", "msg", "cat":
+		if lit.kind != tokString {
+			p.fail(lit.pos, "expected string, got %s", lit)
+			return nil
+		}
+		if op.ordering() {
+			p.fail(opTok.pos, "operator %q can't be used with strings", opTok.text)
+			return nil
+		}
+		n := stringNode{op: op, lit: lit.text}
+		switch field.text {
+		case "name":
+			n.field = fieldName
+			if op == opGlob || op == opNotGlob {
+				n.pattern = strings.Split(lit.text, "/")
+			}
+		case "msg":
+			n.field = fieldMsg
+		case "cat":
+			n.field = fieldCat
+		}
+		p.next()
+		return n
+	}
+	p.fail(field.pos, "unknown field %q (use lvl, name, msg, cat or kv.KEY)", field.text)
+	return nil
+}
+
+func opName(op filterOp) string {
+	for name, o := range filterOps {
+		if o == op {
+			return name
+		}
+	}
+	return "?"
+}
@@ -0,0 +1,173 @@
+package log
+
+import (
+	"bytes"
+	"errors"
+	"github.com/One-com/gonelog/syslog"
+	"strings"
+	"testing"
+)
+
+func filterTestEvent() Event {
+	return Event{&event{
+		Lvl:  syslog.LOG_WARN,
+		Msg:  "connection timeout",
+		Name: "db/pool",
+		Data: []interface{}{
+			"user_id", 42,
+			"host", "db1",
+			"ok", false,
+			"err", errors.New("refused"),
+			KVGroup{Name: "http", KV: []interface{}{"method", "GET"}}, nil,
+		},
+	}}
+}
+
+var filterCorpus = []struct {
+	expr  string
+	match bool
+}{
+	{`lvl <= warn && name =~ "db/*" && kv.user_id == 42 && msg contains "timeout"`, true},
+	{`lvl == warning`, true},
+	{`lvl < warn`, false},
+	{`lvl >= 3`, true},
+	{`lvl > "notice"`, false},
+	{`name == "db/pool"`, true},
+	{`name =~ "db"`, false},
+	{`name =~ "db/**"`, true},
+	{`name !~ "net/**"`, true},
+	{`msg =~ "*time?ut"`, true},
+	{`msg contains "refused"`, false},
+	{`cat == ""`, true},
+	{`kv.user_id != 42`, false},
+	{`kv.user_id >= 40 && kv.user_id < 43.5`, true},
+	{`kv.host == "db1"`, true},
+	{`kv.host == 1`, false},
+	{`kv.host != 1`, true},
+	{`kv.host =~ "db?"`, true},
+	{`kv.ok == false`, true},
+	{`kv.ok != true`, true},
+	{`kv.err contains "refus"`, true},
+	{`kv.http.method == "GET"`, true},
+	{`kv.http`, false},
+	{`kv.missing`, false},
+	{`kv.missing == "x"`, false},
+	{`kv.missing != "x"`, true},
+	{`!kv.missing && kv.host`, true},
+	{`kv.host == "x" || (lvl <= err || msg contains "conn")`, true},
+	{`!(lvl <= warn)`, false},
+	{`kv.user_id == -1 || kv.user_id == 42`, true},
+}
+
+func TestFilterCorpus(t *testing.T) {
+	e := filterTestEvent()
+	for _, test := range filterCorpus {
+		f, err := CompileFilter(test.expr)
+		if err != nil {
+			t.Errorf("%s: %s", test.expr, err)
+			continue
+		}
+		if m := f.Match(e); m != test.match {
+			t.Errorf("%s: got %v", test.expr, m)
+		}
+	}
+}
+
+var filterErrors = []struct {
+	expr string
+	err  string
+}{
+	{``, "expected field"},
+	{`lvl`, "expected operator after \"lvl\", got end of expression at position 3"},
+	{`lvl <= loud`, "unknown level \"loud\" at position 7"},
+	{`lvl contains "x"`, "can't be used with levels"},
+	{`size > 2`, "unknown field \"size\""},
+	{`name < "x"`, "can't be used with strings"},
+	{`msg == 42`, "expected string, got \"42\""},
+	{`kv.x contains 4`, "needs a string"},
+	{`kv.x < "a"`, "needs a number"},
+	{`kv.x == true && kv.y =~ false`, "can't be used with \"false\""},
+	{`kv.x == 1 1`, "unexpected \"1\" at position 10"},
+	{`(lvl == 3`, "missing \")\" for \"(\" at position 0"},
+	{`msg == "abc`, "unterminated or invalid string at position 7"},
+	{`lvl == 3 & msg == "a"`, "unexpected character '&' at position 9"},
+	{`kv..x`, "invalid key"},
+	{`lvl == 3 &&`, "expected field, \"!\" or \"(\", got end of expression"},
+}
+
+func TestFilterErrors(t *testing.T) {
+	for _, test := range filterErrors {
+		_, err := CompileFilter(test.expr)
+		if err == nil {
+			t.Errorf("%s: no error", test.expr)
+			continue
+		}
+		if _, ok := err.(*FilterError); !ok || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("%s: got %q, want %q", test.expr, err, test.err)
+		}
+	}
+}
+
+func TestFilterNoAlloc(t *testing.T) {
+	e := filterTestEvent()
+	f := MustCompileFilter(filterCorpus[0].expr + ` && kv.http.method =~ "G*"`)
+	allocs := testing.AllocsPerRun(100, func() {
+		f.Match(e)
+	})
+	if allocs != 0 {
+		t.Errorf("Match allocates: %v", allocs)
+	}
+}
+
+func TestFilterLazy(t *testing.T) {
+	var n int
+	e := Event{&event{Data: []interface{}{
+		"host", "db1",
+		"a", Lazy(func() interface{} { n++; return n }),
+		LazyKV(func() []interface{} { return []interface{}{"c", 3} }), nil,
+	}}}
+	// No Lazy values involved
+	if !MustCompileFilter(`kv.host == "db1"`).Match(e) || e.rok {
+		t.Error("event data resolved")
+	}
+	if !MustCompileFilter(`kv.a == 1 && kv.c == 3`).Match(e) || !MustCompileFilter(`kv.a == 1`).Match(e) {
+		t.Error("no match")
+	}
+	if n != 1 {
+		t.Errorf("Lazy value evaluated %d times", n)
+	}
+
+	// The formatter logs the value the filter saw
+	var buf bytes.Buffer
+	n = 0
+	l := NewLogger(syslog.LOG_INFO, FilterHandler(MustCompileFilter(`kv.a == 1`).Match, NewStdFormatter(&buf, "", 0)))
+	l.INFO("x", "a", Lazy(func() interface{} { n++; return n }))
+	if buf.String() != "x a=1\n" || n != 1 {
+		t.Errorf("got %q, evaluated %d times", buf.String(), n)
+	}
+}
+
+func TestFilterWriter(t *testing.T) {
+	var buf bytes.Buffer
+	f := MustCompileFilter(`lvl <= warn`)
+	l := NewLogger(syslog.LOG_INFO, NewStdFormatter(FilterWriter(f.Match, &buf), "", Llevel))
+	l.WARN("yes")
+	l.INFO("no")
+	if buf.String() != "<4>yes\n" {
+		t.Errorf("wrong events filtered: %q", buf.String())
+	}
+}
+
+func TestFilterHandler(t *testing.T) {
+	rec := &eventRecorder{}
+	var f Filter
+	if err := f.UnmarshalText([]byte(`kv.user == "bob"`)); err != nil {
+		t.Fatal(err)
+	}
+	l := NewLogger(syslog.LOG_INFO, FilterHandler(f.Match, rec))
+	l.INFO("yes", "user", "bob")
+	l.INFO("no", "user", "alice")
+	if len(rec.events) != 1 || rec.events[0].Msg != "yes" {
+		t.Errorf("wrong events filtered")
+	}
+}
@@ -0,0 +1,58 @@
+package log
+
+import (
+	"flag"
+)
+
+type levelFlag struct {
+	l *Logger
+}
+
+// LevelFlag returns a flag.Value which gets and sets the level of a live Logger.
+// Use it to bind a command line flag directly to a Logger:
+//
+//	flag.Var(log.LevelFlag(log.Default()), "loglevel", "log level (debug, info, warn, ...)")
+//
+// Levels are parsed by ParseLevel
+func LevelFlag(l *Logger) flag.Value {
+	return levelFlag{l: l}
+}
+
+func (f levelFlag) String() string {
+	if f.l == nil {
+		return ""
+	}
+	return LevelName(f.l.Level())
+}
+
+func (f levelFlag) Set(s string) error {
+	lvl, err := ParseLevel(s)
+	if err != nil {
+		return err
+	}
+	for !f.l.SetLevel(lvl) {
+	}
+	return nil
+}
+
+type levelSpecFlag struct {
+	spec *string
+}
+
+// LevelSpecFlag returns a flag.Value applying a level spec (see SetLevelSpec)
+// to the Logger hierarchy when set.
+func LevelSpecFlag() flag.Value {
+	return levelSpecFlag{spec: new(string)}
+}
+
+func (f levelSpecFlag) String() string {
+	if f.spec == nil {
+		return ""
+	}
+	return *f.spec
+}
+
+func (f levelSpecFlag) Set(s string) error {
+	*f.spec = s
+	return SetLevelSpec(s)
+}
@@ -0,0 +1,25 @@
+package log
+
+import (
+	"flag"
+	"github.com/One-com/gonelog/syslog"
+	"testing"
+)
+
+func TestLevelFlag(t *testing.T) {
+	l := NewLogger(syslog.LOG_INFO, nil)
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.Var(LevelFlag(l), "loglevel", "log level")
+	if err := fs.Parse([]string{"-loglevel", "DBG"}); err != nil {
+		t.Fatal(err)
+	}
+	if l.Level() != syslog.LOG_DEBUG {
+		t.Errorf("level not set: %s", l.Level())
+	}
+	if s := fs.Lookup("loglevel").Value.String(); s != "debug" {
+		t.Errorf("flag value %q", s)
+	}
+	if err := fs.Set("loglevel", "loud"); err == nil {
+		t.Error("invalid level accepted")
+	}
+}
@@ -0,0 +1,75 @@
+package log
+
+import (
+	"context"
+	"github.com/One-com/gonelog/syslog"
+)
+
+// ForceLevel returns a child Logger generating events at level and more severe
+// levels regardless of the Logger level. Events still go to the same Handlers.
+// Use it to get DEBUG logs for a single request without changing the level for all:
+//
+//	rl := l.With("req", id)
+//	if debugRequested(r) {
+//		rl = rl.ForceLevel(syslog.LOG_DEBUG)
+//	}
+//
+// Further children of the Logger (from With() etc.) inherit the forced level.
+// A forced level can only make a Logger generate more events, not fewer.
+func (l *Logger) ForceLevel(level syslog.Priority) *Logger {
+	new := l.child()
+	if f := clampLevel(level) + 1; f > new.force {
+		new.force = f
+	}
+	return new
+}
+
+// ForcedLevel returns the level forced on the Logger by ForceLevel(), if any.
+func (l *Logger) ForcedLevel() (syslog.Priority, bool) {
+	return l.force - 1, l.force != 0
+}
+
+type forceKey struct{}
+
+// ContextWithForceLevel returns a context marked to force the level of Loggers
+// used with it through ForContext(). This carries the level override through
+// calls into libraries using their own Loggers from GetLogger().
+func ContextWithForceLevel(ctx context.Context, level syslog.Priority) context.Context {
+	return context.WithValue(ctx, forceKey{}, level)
+}
+
+// ContextForcedLevel returns the level forced by ContextWithForceLevel(), if any.
+func ContextForcedLevel(ctx context.Context) (syslog.Priority, bool) {
+	level, ok := ctx.Value(forceKey{}).(syslog.Priority)
+	return level, ok
+}
+
+// ForContext returns a Logger forcing the level of any ContextWithForceLevel() marker in ctx,
+// and with any pprof labels of ctx if the Logger records goroutine info (DoGoroutineInfo()).
+// If there's nothing to add from ctx, the Logger itself is returned.
+// Libraries getting a context should use it:
+//
+//	var logger = log.GetLogger("mylib")
+//
+//	func Fetch(ctx context.Context, url string) {
+//		l := logger.ForContext(ctx)
+//		l.DEBUG("fetching", "url", url)
+//	}
+func (l *Logger) ForContext(ctx context.Context) *Logger {
+	if ctx == nil {
+		return l
+	}
+	new := l
+	if level, ok := ContextForcedLevel(ctx); ok && clampLevel(level)+1 > l.force {
+		new = l.ForceLevel(level)
+	}
+	if l.DoingGoroutineInfo() {
+		if labels := contextLabels(ctx); labels != nil {
+			if new == l {
+				new = l.child()
+			}
+			new.labels = labels
+		}
+	}
+	return new
+}
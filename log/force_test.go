@@ -0,0 +1,53 @@
+package log
+
+import (
+	"context"
+	"github.com/One-com/gonelog/syslog"
+	"testing"
+)
+
+func TestForceLevel(t *testing.T) {
+	rec := &eventRecorder{}
+	l := NewLogger(syslog.LOG_WARN, rec)
+
+	fl := l.With("req", 1).ForceLevel(syslog.LOG_DEBUG)
+	fl.DEBUG("forced")
+	fl.With("more", 2).INFO("child forced")
+	fl.TRACE("not forced")
+	fl.Println("print forced")
+	l.DEBUG("not forced")
+
+	if lvl, ok := fl.ForcedLevel(); !ok || lvl != syslog.LOG_DEBUG {
+		t.Errorf("wrong forced level: %d %v", lvl, ok)
+	}
+	if len(rec.events) != 3 {
+		t.Fatalf("expected 3 events, got %d", len(rec.events))
+	}
+	if rec.events[0].Msg != "forced" || rec.events[1].Msg != "child forced" || rec.events[2].Msg != "print forced\n" {
+		t.Errorf("wrong events")
+	}
+	if _, ok := l.ForcedLevel(); ok {
+		t.Error("parent Logger forced")
+	}
+}
+
+func TestForContext(t *testing.T) {
+	rec := &eventRecorder{}
+	lib := NewLogger(syslog.LOG_WARN, rec)
+
+	ctx := context.Background()
+	if lib.ForContext(ctx) != lib {
+		t.Error("new Logger for unmarked context")
+	}
+	ctx = ContextWithForceLevel(ctx, syslog.LOG_INFO)
+	lib.ForContext(ctx).INFO("forced by context")
+	lib.ForContext(ctx).DEBUG("not forced")
+
+	dl := lib.ForceLevel(syslog.LOG_DEBUG)
+	if dl.ForContext(ctx) != dl {
+		t.Error("context lowered the forced level")
+	}
+	if len(rec.events) != 1 || rec.events[0].Msg != "forced by context" {
+		t.Errorf("wrong events: %d", len(rec.events))
+	}
+}
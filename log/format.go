@@ -2,7 +2,6 @@ package log
 
 import (
 	"bytes"
-	"fmt"
 	"github.com/One-com/gonelog/syslog"
 	"github.com/One-com/gonelog/term"
 	"gopkg.in/logfmt.v0"
@@ -47,6 +46,14 @@ const (
 	Lcolor // Do color logging to terminals
 	Lname  // Log the name of the Logger generating the event
 
+	Lfunc    // function name of the log call: (*T).Method
+	Lpackage // package import path of the log call. With Lfunc: a/b/c.(*T).Method
+
+	Lcategory // Log the category of the event: [sql]
+
+	Lgoroutine // ID of the logging goroutine: [g17]. Needs DoGoroutineInfo() on the Logger
+	Llabels    // pprof labels of the event: {job=resize}. See Event.Labels()
+
 	LstdFlags = Ldate | Ltime // stdlib compatible
 
 	LminFlags = Llevel // Simple systemd/syslog compatible level spec. Let external log system take care of timestamps etc.
@@ -81,6 +88,13 @@ type stdformatter struct {
 	out    io.Writer
 
 	pfxarr *[8]string // prefixes to log lines for the 8 syslog levels.
+
+	tfmt TimeFormat     // layout of timestamps
+	tloc *time.Location // time zone of timestamps, if not nil
+
+	facility syslog.Priority // syslog facility added to <level> prefixes
+
+	dup DupPolicy // what to do with duplicate keys
 }
 
 // NewMinFormatter creates a standard formatter and applied the supplied options
@@ -125,6 +139,11 @@ func (f *stdformatter) Clone(options ...HandlerOption) CloneableHandler {
 	return new
 }
 
+// Chained returns the Writer of the formatter, to let Shutdown() flush it.
+func (f *stdformatter) Chained() []interface{} {
+	return []interface{}{f.out}
+}
+
 // To support stdlib query functions
 func (f *stdformatter) Prefix() string {
 	return f.prefix
@@ -197,6 +216,18 @@ func OutputOpt(w io.Writer) HandlerOption {
 	}
 }
 
+// FacilityOpt sets the syslog facility (syslog.LOG_DAEMON, syslog.LOG_LOCAL0, ...)
+// to combine with the event level in "<PRI>" prefixes.
+// It only has effect with the default syslog level prefixes. Events with a facility of
+// their own (see Logger.WithFacility()) use that.
+func FacilityOpt(facility syslog.Priority) HandlerOption {
+	return func(c CloneableHandler) {
+		if h, ok := c.(*stdformatter); ok {
+			h.facility = facility.Facility()
+		}
+	}
+}
+
 // Formatter option to set LevelPrefixes
 func LevelPrefixOpt(arr *[8]string) HandlerOption {
 	return func(c CloneableHandler) {
@@ -230,7 +261,7 @@ func itoa(buf *[]byte, i int, wid int) {
 func (f *stdformatter) Log(e Event) error {
 
 	var now time.Time
-	var file string
+	var file, fn string
 	var line int
 
 	msg := e.Msg
@@ -239,30 +270,49 @@ func (f *stdformatter) Log(e Event) error {
 
 	if f.flag == LminFlags { // Minimal mode
 		xbuf = append(xbuf, '<')
-		itoa(&xbuf, int(e.Lvl), 1)
+		itoa(&xbuf, int(f.eventFacility(e)|SyslogLevel(e.Lvl)), 1)
 		xbuf = append(xbuf, '>')
 		xbuf = append(xbuf, f.prefix...) // add any custom prefix
 
 	} else {
-		if f.flag&(Lshortfile|Llongfile) != 0 {
+		if f.flag&(Lshortfile|Llongfile|Lfunc|Lpackage) != 0 {
 			if e.fok {
 				file, line = e.FileInfo()
+				fn = e.FuncInfo()
 			} else {
 				file = "???"
 				line = 0
+				fn = "???"
 			}
 		}
 		if f.flag&(Ldate|Ltime|Lmicroseconds) != 0 {
 			now = e.Time()
 		}
-		f.formatHeader(&xbuf, e.Lvl, now, e.Name, file, line)
+		f.formatHeader(&xbuf, e.Lvl, f.eventFacility(e), now, e.Name, file, line, fn, e.cat)
+		if f.flag&Lgoroutine != 0 && e.goid != 0 {
+			xbuf = append(xbuf, "[g"...)
+			itoa(&xbuf, int(e.goid), -1)
+			xbuf = append(xbuf, "] "...)
+		}
+		if f.flag&Llabels != 0 && len(e.labels) > 1 {
+			xbuf = append(xbuf, '{')
+			for i := 0; i+1 < len(e.labels); i += 2 {
+				if i > 0 {
+					xbuf = append(xbuf, ',')
+				}
+				xbuf = append(xbuf, e.labels[i]...)
+				xbuf = append(xbuf, '=')
+				xbuf = append(xbuf, e.labels[i+1]...)
+			}
+			xbuf = append(xbuf, "} "...)
+		}
 	}
 
 	xbuf = append(xbuf, msg...)
 
-	if len(e.Data) > 0 {
+	if data := e.ResolvedData(); len(data) > 0 {
 		xbuf = append(xbuf, ' ')
-		marshalKeyvals(&buf.Buffer, e.Data...)
+		marshalKeyvals(&buf.Buffer, flatKeyvals(data, f.dup)...)
 		xbuf = append(xbuf, buf.Buffer.Bytes()...)
 	}
 
@@ -295,9 +345,6 @@ func marshalKeyvals(w io.Writer, keyvals ...interface{}) error {
 	enc := logfmt.NewEncoder(w)
 	for i := 0; i < len(keyvals); i += 2 {
 		k, v := keyvals[i], keyvals[i+1]
-		if l, ok := v.(Lazy); ok {
-			v = l.evaluate()
-		}
 		err := enc.EncodeKeyval(k, v)
 		if err == logfmt.ErrUnsupportedKeyType {
 			continue
@@ -313,16 +360,32 @@ func marshalKeyvals(w io.Writer, keyvals ...interface{}) error {
 	return nil
 }
 
-func (l *stdformatter) formatHeader(buf *[]byte, level syslog.Priority, t time.Time, name string, file string, line int) {
+// eventFacility returns the facility of the event, or else of the formatter.
+func (f *stdformatter) eventFacility(e Event) syslog.Priority {
+	if e.fac != 0 {
+		return e.fac
+	}
+	return f.facility
+}
+
+func (l *stdformatter) formatHeader(buf *[]byte, level syslog.Priority, fac syslog.Priority, t time.Time, name string, file string, line int, fn string, cat uint32) {
 
 	if l.flag&(Llevel) != 0 {
 		if l.flag&(Lcolor) != 0 {
-			*buf = append(*buf,
-				fmt.Sprintf("\x1b[%sm%s\x1b[0m",
-					level_colors[level],
-					(*l.pfxarr)[level])...)
+			*buf = append(*buf, "\x1b["...)
+			*buf = append(*buf, levelColor(level)...)
+			*buf = append(*buf, 'm')
+		}
+		if fac != 0 && l.pfxarr == &syslog_lvlpfx {
+			// <PRI> has to be calculated
+			*buf = append(*buf, '<')
+			itoa(buf, int(fac|SyslogLevel(level)), 1)
+			*buf = append(*buf, '>')
 		} else {
-			*buf = append(*buf, (*l.pfxarr)[level]...) // level prefix
+			*buf = append(*buf, levelPrefix(l.pfxarr, level)...) // level prefix
+		}
+		if l.flag&(Lcolor) != 0 {
+			*buf = append(*buf, "\x1b[0m"...)
 		}
 	}
 
@@ -334,11 +397,22 @@ func (l *stdformatter) formatHeader(buf *[]byte, level syslog.Priority, t time.T
 		*buf = append(*buf, ") "...)
 	}
 
+	if l.flag&(Lcategory) != 0 && cat != 0 {
+		*buf = append(*buf, '[')
+		*buf = append(*buf, Category(cat).String()...)
+		*buf = append(*buf, "] "...)
+	}
+
 	if l.flag&(Ldate|Ltime|Lmicroseconds) != 0 {
-		if l.flag&LUTC != 0 {
+		if l.tloc != nil {
+			t = t.In(l.tloc)
+		} else if l.flag&LUTC != 0 {
 			t = t.UTC()
 		}
-		if l.flag&Ldate != 0 {
+		if l.tfmt != TimeStd {
+			appendTime(buf, t, l.tfmt)
+			*buf = append(*buf, ' ')
+		} else if l.flag&Ldate != 0 {
 			year, month, day := t.Date()
 			itoa(buf, year, 4)
 			*buf = append(*buf, '/')
@@ -347,7 +421,7 @@ func (l *stdformatter) formatHeader(buf *[]byte, level syslog.Priority, t time.T
 			itoa(buf, day, 2)
 			*buf = append(*buf, ' ')
 		}
-		if l.flag&(Ltime|Lmicroseconds) != 0 {
+		if l.tfmt == TimeStd && l.flag&(Ltime|Lmicroseconds) != 0 {
 			hour, min, sec := t.Clock()
 			itoa(buf, hour, 2)
 			*buf = append(*buf, ':')
@@ -385,4 +459,14 @@ func (l *stdformatter) formatHeader(buf *[]byte, level syslog.Priority, t time.T
 		*buf = append(*buf, ": "...)
 	}
 
+	if l.flag&(Lfunc|Lpackage) != 0 {
+		switch l.flag & (Lfunc | Lpackage) {
+		case Lfunc:
+			_, fn = splitFuncName(fn)
+		case Lpackage:
+			fn, _ = splitFuncName(fn)
+		}
+		*buf = append(*buf, fn...)
+		*buf = append(*buf, ": "...)
+	}
 }
@@ -15,25 +15,30 @@ const LvlDEFAULT syslog.Priority = syslog.LOG_INFO
 // a Logger and which needs sync/atomic ops for access, so we can't allow the
 // user to copy them, when a logger is copied.
 type lconfig struct {
-	config uint32 // atomic data (should be cheap since it's an 32-bit well aligned)
+	config uint32       // atomic data (should be cheap since it's an 32-bit well aligned)
+	clock  atomic.Value // clockHolder with any Logger specific Clock
 }
 
 // lconfig uint32 mask
 const (
-	levelshift = 3
+	levelshift = 4
 
-	// Use the low 8 bit for the basic stuff.
-	// 3 bit loglevel, 3 bit defaultlevel, 1 bit dotime, 1 bit docode
-	// This leaves potentially 24 bit for something else - like log-categories
-	maskLogLvl uint32 = 0x00000007 // The log level determining which events are generated
-	maskDefLvl uint32 = 0x00000038 // The log level for Print*() statements
-	maskDoCode uint32 = 0x00000040 // attach file/line info to the events.
-	maskDoTime uint32 = 0x00000080 // pre-timestamp events.
+	// Use the low 12 bit for the basic stuff.
+	// 4 bit loglevel, 4 bit defaultlevel, 1 bit docode, 1 bit dotime, 1 bit default obligatory,
+	// 1 bit goroutine info
+	// The log levels are the 8 syslog levels and up to 8 additional levels (like TRACE)
+	// This leaves 20 bit for something else. The upper 16 bits are log-categories
+	maskLogLvl uint32 = 0x0000000f // The log level determining which events are generated
+	maskDefLvl uint32 = 0x000000f0 // The log level for Print*() statements
+	maskDoCode uint32 = 0x00000100 // attach file/line info to the events.
+	maskDoTime uint32 = 0x00000200 // pre-timestamp events.
 
-	maskDefObl uint32 = 0x00000100 // Generate Print*() events despite log level.
+	maskDefObl uint32 = 0x00000400 // Generate Print*() events despite log level.
+	maskDoGor  uint32 = 0x00000800 // attach goroutine ID and pprof labels to the events.
 
 	// The default logger has default level and Print*() logging will *not* obey levels.
-	defConfig uint32 = (uint32(LvlDEFAULT) << levelshift) | uint32(LvlDEFAULT) | maskDefObl
+	// All categories are enabled.
+	defConfig uint32 = (uint32(LvlDEFAULT) << levelshift) | uint32(LvlDEFAULT) | maskDefObl | maskCategories
 )
 
 // Logger implements the gonelog.Logger interface through which all logging is done.
@@ -72,13 +77,39 @@ type Logger struct {
 	cparent *Logger
 
 	// K/V Attributes common to all events logged ... Using a slice instead of map for speed
+	// Those of the Logger first, then those of its context parents not replaced by the Logger.
 	data []interface{}
+
+	// Additional stack frames to skip when recording code info.
+	skip int
+
+	// Category bit events are tagged with. 0 for none.
+	cat uint32
+
+	// Groups (from WithGroup) to place K/V data in.
+	groups []string
+
+	// Events at this level and more severe are generated regardless of the config.
+	// 0 for none, else the level + 1
+	force syslog.Priority
+
+	// pprof labels (key, value, ...) from the context given to ForContext()
+	labels []string
+
+	// syslog facility for events logged without one. 0 for none.
+	fac syslog.Priority
+
+	// config bits (maskDoTime, maskDoCode) in effect regardless of the config.
+	do uint32
+
+	// LevelLoggers from Every() etc. - map[limitParams]*limitedLogger, copy on write
+	limits atomic.Value
 }
 
 // NewLogger creates a new unamed Logger out side of the named Logger hierarchy.
 func NewLogger(level syslog.Priority, handler Handler) (l *Logger) {
 
-	i := defConfig & ^maskLogLvl | (uint32(level) & maskLogLvl)
+	i := defConfig & ^maskLogLvl | uint32(levelNumber(clampLevel(level)))
 	c := &lconfig{config: i}
 	l = &Logger{
 		name: "", // not a part of hierarchy
@@ -130,6 +161,12 @@ func (l *Logger) SetHandler(h Handler) {
 	l.h.SwapHandler(h)
 }
 
+// Handler returns the Handler currently attached to the Logger (or nil).
+// Events might still be handled by a parent Logger in the name hierarchy.
+func (l *Logger) Handler() Handler {
+	return l.h.handler()
+}
+
 // ApplyHandlerOptions clones the current Handles and tries to apply the supplied
 // HandlerOptions to the clone - then swaps in the clone atomically to not loose
 // Log events.
@@ -149,21 +186,63 @@ func (l *Logger) AutoColoring() {
 }
 
 // With ties a sub-Context to the Logger.
+// Keys already in the context of the Logger get the new values (not duplicates).
 func (l *Logger) With(kv ...interface{}) *Logger {
 	d := normalize(kv)
-	// copy the pointers to handler and config to ease access later
+	if l.groups != nil {
+		d = inGroups(l.groups, d)
+	}
 	// For all purposes except data, this child will be the same as it's cparent.
-	new := &Logger{
-		name: l.name,
-		cfg:  l.cfg,
-		h:    l.h,
-		// Limiting the capacity of the stored keyvals ensures that a new
-		// backing array is created if the slice must grow
-		// Using the extra capacity without copying risks a data race that
-		// would violate the Logger interface contract.
-		data:    d[:len(d):len(d)],
+	new := l.child()
+	if len(l.data) != 0 {
+		if parent := withoutKeys(l.data, flatKeySet(d), ""); len(parent) != 0 {
+			d = append(d[:len(d):len(d)], parent...)
+		}
+	}
+	// Limiting the capacity of the stored keyvals ensures that a new
+	// backing array is created if the slice must grow
+	// Using the extra capacity without copying risks a data race that
+	// would violate the Logger interface contract.
+	new.data = d[:len(d):len(d)]
+	return new
+}
+
+// child returns a context child of the Logger without any data of its own.
+// The pointers to handler and config are copied to ease access later.
+func (l *Logger) child() *Logger {
+	return &Logger{
+		name:    l.name,
+		cfg:     l.cfg,
+		h:       l.h,
 		cparent: l,
+		data:    l.data,
+		skip:    l.skip,
+		cat:     l.cat,
+		groups:  l.groups,
+		force:   l.force,
+		labels:  l.labels,
+		fac:     l.fac,
+		do:      l.do,
 	}
+}
+
+// WithCallerSkip returns a child Logger which skips n additional stack frames
+// when recording code info (file/line/function) for events.
+// Use it when wrapping a Logger in your own logging functions to have the
+// events report the location of the caller of your wrapper.
+func (l *Logger) WithCallerSkip(n int) *Logger {
+	new := l.child()
+	new.skip += n
+	return new
+}
+
+// WithFacility returns a child Logger tagging events with a syslog facility
+// (syslog.LOG_DAEMON, syslog.LOG_LOCAL0, ...), unless they are logged with a priority
+// having one, like Log(syslog.LOG_LOCAL1|syslog.LOG_ERR, ...).
+// The facility is used in "<PRI>" prefixes instead of any FacilityOpt of the formatter.
+func (l *Logger) WithFacility(facility syslog.Priority) *Logger {
+	new := l.child()
+	new.fac = facility.Facility()
 	return new
 }
 
@@ -186,6 +265,21 @@ func (l *Logger) DoTime(do_time bool) bool {
 	return atomic.CompareAndSwapUint32(&l.cfg.config, c, n)
 }
 
+// DoGoroutineInfo tries to turn on or off recording the ID of the logging goroutine
+// and any pprof labels for the events. See ForContext() for the labels.
+// It can fail if some other go-routine simultaneous is manipulating the config.
+// Returning whether the change was successful
+func (l *Logger) DoGoroutineInfo(do_gor bool) bool {
+	c := atomic.LoadUint32(&l.cfg.config)
+	var n uint32
+	if do_gor {
+		n = c | maskDoGor
+	} else {
+		n = c & ^maskDoGor
+	}
+	return atomic.CompareAndSwapUint32(&l.cfg.config, c, n)
+}
+
 // DoCodeInfo tries to turn on or off registering the file and line of the log call.
 // Formatters which try to log this info will not give meaningful info if this is turned off.
 // It can fail if some other go-routine simultaneous is manipulating the config.
@@ -206,8 +300,8 @@ func (l *Logger) IncLevel() bool {
 	c := atomic.LoadUint32(&l.cfg.config)
 	var n uint32
 	n = (c & maskLogLvl)
-	if n >= uint32(syslog.LOG_DEBUG) {
-		n = uint32(syslog.LOG_DEBUG)
+	if n >= uint32(levelNumber(maxLevel)) {
+		n = uint32(levelNumber(maxLevel))
 	} else {
 		n++
 	}
@@ -232,12 +326,10 @@ func (l *Logger) DecLevel() bool {
 // SetLevel set the Logger log level.
 // returns success
 func (l *Logger) SetLevel(level syslog.Priority) bool {
-	if level > syslog.LOG_DEBUG {
-		level = syslog.LOG_DEBUG
-	}
+	level = clampLevel(level)
 	c := atomic.LoadUint32(&l.cfg.config)
 	var n uint32
-	n = (c & ^maskLogLvl) | uint32(level)
+	n = (c & ^maskLogLvl) | uint32(levelNumber(level))
 	return atomic.CompareAndSwapUint32(&l.cfg.config, c, n)
 }
 
@@ -254,12 +346,10 @@ func (l *Logger) SetDefaultLevel(level syslog.Priority, respect bool) bool {
 // systems like syslog.
 // returns success
 func (l *Logger) SetPrintLevel(level syslog.Priority, respect bool) bool {
-	if level > syslog.LOG_DEBUG {
-		level = syslog.LOG_DEBUG
-	}
+	level = clampLevel(level)
 	c := atomic.LoadUint32(&l.cfg.config)
 	var n uint32
-	n = (c & ^maskDefLvl) | (uint32(level) << levelshift)
+	n = (c & ^maskDefLvl) | (uint32(levelNumber(level)) << levelshift)
 	if respect {
 		n &^= maskDefObl
 	} else {
@@ -270,8 +360,10 @@ func (l *Logger) SetPrintLevel(level syslog.Priority, respect bool) bool {
 
 // Does returns whether the Logger would generate an event at this level?
 // This can be used for optimal performance logging
+// If the Logger tags events with a category, it has to be enabled too.
 func (l *Logger) Does(level syslog.Priority) bool {
-	return level <= l.cfg.level()
+	level = severity(level)
+	return level < l.force || l.cfg.does(level, l.cat)
 }
 
 // Do is Setlevel() - For completeness
@@ -286,7 +378,7 @@ func (l *Logger) Do(level syslog.Priority) bool {
 // generate a log event with the current config.
 // It's equivalent to l.Does(l.PrintLevel()) - but atomically
 func (l *Logger) DoingPrintLevel() (syslog.Priority, bool) {
-	return l.cfg.doing_default_level()
+	return l.doingPrintLevel()
 }
 
 // DoingDefaultLevel returns whether a log.Println() would actually
@@ -294,7 +386,12 @@ func (l *Logger) DoingPrintLevel() (syslog.Priority, bool) {
 // It's equivalent to l.Does(l.DefaultLevel()) - but atomically
 // Deprecated, use DoingPrintLevel()
 func (l *Logger) DoingDefaultLevel() (syslog.Priority, bool) {
-	return l.cfg.doing_default_level()
+	return l.doingPrintLevel()
+}
+
+func (l *Logger) doingPrintLevel() (syslog.Priority, bool) {
+	lvl, ok := l.cfg.doing_default_level(l.cat)
+	return lvl, ok || lvl < l.force
 }
 
 // Level returns the current log level
@@ -324,32 +421,47 @@ func (l *Logger) DoingCodeInfo() bool {
 	return l.cfg.doing_code()
 }
 
+// DoingGoroutineInfo returns whether the Logger is currently recording goroutine
+// ID and pprof labels for all log events
+func (l *Logger) DoingGoroutineInfo() bool {
+	return atomic.LoadUint32(&l.cfg.config)&maskDoGor != 0
+}
+
 /********************** lconfig operations *************************/
 
 func (lc *lconfig) clone() *lconfig {
-	return &lconfig{
+	n := &lconfig{
 		config: atomic.LoadUint32(&lc.config),
 	}
+	if h, ok := lc.clock.Load().(clockHolder); ok {
+		n.clock.Store(h)
+	}
+	return n
 }
 
 func (lc *lconfig) level() (l syslog.Priority) {
 	c := atomic.LoadUint32(&lc.config)
-	l = syslog.Priority(c & maskLogLvl)
+	l = numberLevel(int(c & maskLogLvl))
 	return
 }
 
 func (lc *lconfig) default_level() (l syslog.Priority) {
 	c := atomic.LoadUint32(&lc.config)
-	l = syslog.Priority(c & maskDefLvl >> levelshift)
+	l = numberLevel(int(c & maskDefLvl >> levelshift))
 	return
 }
 
-func (lc *lconfig) doing_default_level() (syslog.Priority, bool) {
+func (lc *lconfig) doing_default_level(cat uint32) (syslog.Priority, bool) {
 	c := atomic.LoadUint32(&lc.config)
-	l := syslog.Priority(c & maskLogLvl)
-	d := syslog.Priority((c & maskDefLvl) >> levelshift)
+	l := c & maskLogLvl
+	d := (c & maskDefLvl) >> levelshift
 	respect := (c & maskDefObl) == 0
-	return d, ((d <= l) || !respect)
+	return numberLevel(int(d)), ((d <= l) || !respect) && (cat == 0 || c&cat != 0)
+}
+
+func (lc *lconfig) does(level syslog.Priority, cat uint32) bool {
+	c := atomic.LoadUint32(&lc.config)
+	return uint32(levelNumber(level)) <= c&maskLogLvl && (cat == 0 || c&cat != 0)
 }
 
 func (lc *lconfig) doing_time() bool {
@@ -362,7 +474,7 @@ func (lc *lconfig) doing_code() bool {
 	return c&maskDoCode != 0
 }
 
-func (lc *lconfig) doing() (time, code bool) {
+func (lc *lconfig) doing() (time, code, gor bool) {
 	c := atomic.LoadUint32(&lc.config)
-	return (c&maskDoTime != 0), (c&maskDoCode != 0)
+	return (c&maskDoTime != 0), (c&maskDoCode != 0), (c&maskDoGor != 0)
 }
@@ -0,0 +1,54 @@
+package log
+
+import (
+	"context"
+	"runtime"
+	"runtime/pprof"
+)
+
+// goroutineID parses the ID of the current goroutine from the stack trace header
+// "goroutine 18 [running]:"
+func goroutineID() (id uint64) {
+	var buf [32]byte
+	b := buf[:runtime.Stack(buf[:], false)]
+	const pfx = "goroutine "
+	if len(b) < len(pfx) {
+		return 0
+	}
+	for _, c := range b[len(pfx):] {
+		if c < '0' || c > '9' {
+			break
+		}
+		id = id*10 + uint64(c-'0')
+	}
+	return id
+}
+
+// contextLabels returns the pprof labels of the context as key, value, ...
+func contextLabels(ctx context.Context) (labels []string) {
+	pprof.ForLabels(ctx, func(key, value string) bool {
+		labels = append(labels, key, value)
+		return true
+	})
+	return
+}
+
+// Goroutine returns the ID of the goroutine which logged the event, if the Logger
+// records goroutine info (DoGoroutineInfo()).
+func (e Event) Goroutine() (uint64, bool) {
+	return e.goid, e.goid != 0
+}
+
+// Labels returns the pprof labels of the event as key, value, ... if the Logger
+// records goroutine info.
+// There's no way to get the labels of a goroutine except through the context
+// passed by pprof.Do(). So they are only there for events logged with a Logger
+// from ForContext() with that context:
+//
+//	pprof.Do(ctx, pprof.Labels("job", name), func(ctx context.Context) {
+//		l := l.ForContext(ctx)
+//		...
+//	})
+func (e Event) Labels() []string {
+	return e.labels
+}
@@ -0,0 +1,48 @@
+package log
+
+import (
+	"bytes"
+	"context"
+	"github.com/One-com/gonelog/syslog"
+	"regexp"
+	"runtime/pprof"
+	"strings"
+	"testing"
+)
+
+func TestGoroutineInfo(t *testing.T) {
+	var std, js bytes.Buffer
+	l := NewLogger(syslog.LOG_INFO, MultiHandler(
+		NewStdFormatter(&std, "", Llevel|Lgoroutine|Llabels),
+		NewJSONFormatter(&js)))
+
+	l.INFO("off")
+	if !l.DoGoroutineInfo(true) || !l.DoingGoroutineInfo() {
+		t.Fatal("goroutine info not enabled")
+	}
+	pprof.Do(context.Background(), pprof.Labels("job", "resize", "id", "7"), func(ctx context.Context) {
+		l.ForContext(ctx).INFO("on")
+	})
+
+	lines := strings.Split(std.String(), "\n")
+	if lines[0] != "<6>off" {
+		t.Errorf("goroutine info when disabled: %q", lines[0])
+	}
+	if !regexp.MustCompile(`^<6>\[g[1-9][0-9]*\] \{id=7,job=resize\} on$`).MatchString(lines[1]) {
+		t.Errorf("wrong goroutine info: %q", lines[1])
+	}
+	if !regexp.MustCompile(`"_goid":[1-9][0-9]*,"_labels":\{"id":"7","job":"resize"\}`).MatchString(js.String()) {
+		t.Errorf("wrong JSON goroutine info: %s", js.String())
+	}
+}
+
+func TestGoroutineID(t *testing.T) {
+	ids := make(chan uint64, 2)
+	for i := 0; i < 2; i++ {
+		go func() { ids <- goroutineID() }()
+	}
+	a, b := <-ids, <-ids
+	if a == 0 || b == 0 || a == b || a == goroutineID() {
+		t.Errorf("bad goroutine IDs: %d %d", a, b)
+	}
+}
@@ -0,0 +1,276 @@
+package log
+
+import (
+	"fmt"
+)
+
+// KVGroup is a named group of key/value pairs. It takes the place of a key/value pair.
+// Formatters write the keys of the group prefixed by the group name and a ".",
+// like "req.method=GET", or as a nested object in JSON.
+type KVGroup struct {
+	Name string
+	KV   []interface{}
+}
+
+// Group returns a KVGroup to pass in place of a key/value pair:
+//
+//	l.INFO("request", log.Group("req", "method", r.Method, "path", r.URL.Path))
+func Group(name string, kv ...interface{}) KVGroup {
+	return KVGroup{Name: name, KV: normalize(kv)}
+}
+
+// WithGroup returns a child Logger placing all further key/value data in the named group.
+// Both data added with With() on the child and event data.
+//
+//	hl := l.WithGroup("http")
+//	hl.INFO("request", "method", "GET") // http.method=GET
+func (l *Logger) WithGroup(name string) *Logger {
+	groups := make([]string, len(l.groups)+1)
+	copy(groups, l.groups)
+	groups[len(l.groups)] = name
+	new := l.child()
+	new.groups = groups
+	return new
+}
+
+// inGroups places the key/value data in the nested groups
+func inGroups(groups []string, kv []interface{}) []interface{} {
+	for i := len(groups) - 1; i >= 0; i-- {
+		kv = []interface{}{KVGroup{Name: groups[i], KV: kv}, nil}
+	}
+	return kv
+}
+
+// DupPolicy decides what formatters do with duplicate keys in an event.
+type DupPolicy int
+
+const (
+	DupLastWins  DupPolicy = iota // Only the last value is written.
+	DupFirstWins                  // Only the first value is written.
+	DupKeepAll                    // All values are written. JSON gets an array of the values.
+)
+
+// DupPolicyOpt sets the duplicate key policy of a formatter. Default is DupLastWins.
+// Keys are compared after group names are added, so "id" and "http.id" are different keys.
+// The data of an event is its context data (With() data of the Logger and its context parents)
+// followed by the event data, so with DupLastWins event data overrides context data.
+// With() replaces context data with the same keys, so the most specific context data is used.
+func DupPolicyOpt(p DupPolicy) HandlerOption {
+	return func(c CloneableHandler) {
+		switch h := c.(type) {
+		case *stdformatter:
+			h.dup = p
+		case *jsonformatter:
+			h.dup = p
+		}
+	}
+}
+
+func keyString(k interface{}) string {
+	switch x := k.(type) {
+	case string:
+		return x
+	case fmt.Stringer:
+		return safeString(x)
+	}
+	return fmt.Sprint(k)
+}
+
+// Events with more key/value pairs than this use a map to find duplicate keys,
+// instead of comparing all pairs.
+const smallKV = 8
+
+// flatKeyvals expands groups into keys prefixed with the group names and applies
+// the duplicate key policy. keyvals is returned as is, if there's nothing to do.
+func flatKeyvals(keyvals []interface{}, policy DupPolicy) []interface{} {
+	hasGroup := false
+	for i := 0; i < len(keyvals); i += 2 {
+		if _, ok := keyvals[i].(KVGroup); ok {
+			hasGroup = true
+			break
+		}
+	}
+	if !hasGroup && (policy == DupKeepAll || !hasDupKeys(keyvals)) {
+		return keyvals
+	}
+	flat := appendFlat(make([]interface{}, 0, len(keyvals)), "", keyvals)
+	if policy == DupKeepAll {
+		return flat
+	}
+	// The flat keys are all strings
+	out := flat[:0:0]
+	if len(flat)/2 > smallKV {
+		// index of the pair to keep for each key
+		keep := make(map[string]int, len(flat)/2)
+		for i := 0; i+1 < len(flat); i += 2 {
+			k := flat[i].(string)
+			if _, seen := keep[k]; !seen || policy == DupLastWins {
+				keep[k] = i
+			}
+		}
+		for i := 0; i+1 < len(flat); i += 2 {
+			if keep[flat[i].(string)] == i {
+				out = append(out, flat[i], flat[i+1])
+			}
+		}
+		return out
+	}
+	for i := 0; i+1 < len(flat); i += 2 {
+		k := flat[i].(string)
+		dup := false
+		if policy == DupFirstWins {
+			for j := 0; j < i; j += 2 {
+				if flat[j].(string) == k {
+					dup = true
+					break
+				}
+			}
+		} else {
+			for j := i + 2; j < len(flat); j += 2 {
+				if flat[j].(string) == k {
+					dup = true
+					break
+				}
+			}
+		}
+		if !dup {
+			out = append(out, k, flat[i+1])
+		}
+	}
+	return out
+}
+
+func appendFlat(dst []interface{}, prefix string, keyvals []interface{}) []interface{} {
+	for i := 0; i+1 < len(keyvals); i += 2 {
+		if g, ok := keyvals[i].(KVGroup); ok {
+			dst = appendFlat(dst, prefix+g.Name+".", g.KV)
+			continue
+		}
+		dst = append(dst, prefix+keyString(keyvals[i]), keyvals[i+1])
+	}
+	return dst
+}
+
+// flatKeySet returns the keys of keyvals, with group names added.
+func flatKeySet(keyvals []interface{}) map[string]struct{} {
+	flat := appendFlat(nil, "", keyvals)
+	keys := make(map[string]struct{}, len(flat)/2)
+	for i := 0; i < len(flat); i += 2 {
+		keys[flat[i].(string)] = struct{}{}
+	}
+	return keys
+}
+
+// withoutKeys returns keyvals without the keys in the set (with group names added).
+// Groups left empty are removed. keyvals is returned as is, if it has none of the keys.
+func withoutKeys(keyvals []interface{}, keys map[string]struct{}, prefix string) []interface{} {
+	out, _ := removeKeys(keyvals, keys, prefix)
+	return out
+}
+
+func removeKeys(keyvals []interface{}, keys map[string]struct{}, prefix string) ([]interface{}, bool) {
+	var out []interface{}
+	for i := 0; i+1 < len(keyvals); i += 2 {
+		k, v := keyvals[i], keyvals[i+1]
+		keep, changed := true, false
+		switch x := k.(type) {
+		case KVGroup:
+			if kv, removed := removeKeys(x.KV, keys, prefix+x.Name+"."); removed {
+				k, keep, changed = KVGroup{Name: x.Name, KV: kv}, len(kv) != 0, true
+			}
+		case LazyKV:
+		default:
+			_, changed = keys[prefix+keyString(k)]
+			keep = !changed
+		}
+		if out == nil {
+			if !changed {
+				continue
+			}
+			out = append(make([]interface{}, 0, len(keyvals)), keyvals[:i]...)
+		}
+		if keep {
+			out = append(out, k, v)
+		}
+	}
+	if out == nil {
+		return keyvals, false
+	}
+	return out, true
+}
+
+// hasDupKeys tells whether there might be duplicate keys.
+// Non-string keys are not converted, but reported as possible duplicates.
+func hasDupKeys(keyvals []interface{}) bool {
+	n := len(keyvals) / 2
+	if n < 2 {
+		return false
+	}
+	if n > smallKV {
+		seen := make(map[string]struct{}, n)
+		for i := 0; i+1 < len(keyvals); i += 2 {
+			k, ok := keyvals[i].(string)
+			if !ok {
+				return true
+			}
+			if _, dup := seen[k]; dup {
+				return true
+			}
+			seen[k] = struct{}{}
+		}
+		return false
+	}
+	for i := 0; i+1 < len(keyvals); i += 2 {
+		k, ok := keyvals[i].(string)
+		if !ok {
+			return true
+		}
+		for j := 0; j < i; j += 2 {
+			if keyvals[j].(string) == k {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// dupValues are the values of a duplicate key with DupKeepAll
+type dupValues []interface{}
+
+// putValue sets the key in the JSON map according to the policy
+func putValue(m map[string]interface{}, key string, v interface{}, policy DupPolicy) {
+	old, exists := m[key]
+	switch {
+	case !exists || policy == DupLastWins:
+		m[key] = v
+	case policy == DupKeepAll:
+		if d, ok := old.(dupValues); ok {
+			m[key] = append(d, v)
+		} else {
+			m[key] = dupValues{old, v}
+		}
+	}
+}
+
+// mergeGroup merges a KVGroup into the JSON map as a nested object.
+// Groups with the same name are merged. A group and another value with the same
+// key are duplicates like any other values.
+func mergeGroup(m map[string]interface{}, g KVGroup, policy DupPolicy) {
+	var sub map[string]interface{}
+	switch old := m[g.Name].(type) {
+	case map[string]interface{}:
+		sub = old
+	case dupValues:
+		sub, _ = old[len(old)-1].(map[string]interface{})
+	}
+	if sub == nil {
+		if _, exists := m[g.Name]; exists && policy == DupFirstWins {
+			return
+		}
+		sub = make(map[string]interface{}, len(g.KV)/2)
+		putValue(m, g.Name, sub, policy)
+	}
+	for i := 0; i+1 < len(g.KV); i += 2 {
+		merge(sub, g.KV[i], g.KV[i+1], policy)
+	}
+}
@@ -0,0 +1,100 @@
+package log
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/One-com/gonelog/syslog"
+	"strings"
+	"testing"
+)
+
+func TestGroups(t *testing.T) {
+	var std, js bytes.Buffer
+	l := NewLogger(syslog.LOG_INFO, MultiHandler(
+		NewStdFormatter(&std, "", Llevel),
+		NewJSONFormatter(&js)))
+
+	hl := l.With("id", 0, "user", "bob").With("id", 1).WithGroup("http").With("id", 2, "method", "HEAD").With("id", 3)
+	hl.INFO("request", "method", "GET", Group("req", "path", "/", "n", Lazy(func() interface{} { return 3 })))
+
+	// Context keys are replaced by children, event data wins over context data
+	want := `<6>request http.id=3 id=1 user=bob http.method=GET http.req.path=/ http.req.n=3` + "\n"
+	if std.String() != want {
+		t.Errorf("got %q, want %q", std.String(), want)
+	}
+	if s := js.String(); !strings.Contains(s, `"http":{"id":3,"method":"GET","req":{"n":3,"path":"/"}}`) || !strings.Contains(s, `"id":1`) {
+		t.Errorf("wrong JSON: %s", s)
+	}
+}
+
+func TestDupPolicy(t *testing.T) {
+	tests := []struct {
+		policy DupPolicy
+		std    string
+		json   string
+	}{
+		{DupLastWins, "<6>dup b=2 a=3", `"a":3,"b":2`},
+		{DupFirstWins, "<6>dup a=1 b=2", `"a":1,"b":2`},
+		{DupKeepAll, "<6>dup a=1 b=2 a=3", `"a":[1,3],"b":2`},
+	}
+	for _, test := range tests {
+		var std, js bytes.Buffer
+		l := NewLogger(syslog.LOG_INFO, MultiHandler(
+			NewStdFormatter(&std, "", Llevel).Clone(DupPolicyOpt(test.policy)),
+			NewJSONFormatter(&js, DupPolicyOpt(test.policy))))
+		l.With("a", 1).INFO("dup", "b", 2, "a", 3)
+		if s := strings.TrimSpace(std.String()); s != test.std {
+			t.Errorf("policy %d: got %q, want %q", test.policy, s, test.std)
+		}
+		if !strings.Contains(js.String(), test.json) {
+			t.Errorf("policy %d: JSON %s doesn't contain %s", test.policy, js.String(), test.json)
+		}
+	}
+}
+
+func TestJSONGroupDups(t *testing.T) {
+	tests := []struct {
+		policy DupPolicy
+		json   string
+	}{
+		{DupLastWins, `"http":{"a":1,"b":2}`},
+		{DupFirstWins, `"http":"x"`},
+		{DupKeepAll, `"http":["x",{"a":1,"b":2}]`},
+	}
+	for _, test := range tests {
+		var js bytes.Buffer
+		l := NewLogger(syslog.LOG_INFO, NewJSONFormatter(&js, DupPolicyOpt(test.policy)))
+		l.INFO("dup", "http", "x", Group("http", "a", 1), Group("http", "b", 2))
+		if !strings.Contains(js.String(), test.json) {
+			t.Errorf("policy %d: JSON %s doesn't contain %s", test.policy, js.String(), test.json)
+		}
+	}
+}
+
+type stringerKey string
+
+func (k stringerKey) String() string { return string(k) }
+
+func TestFlatKeyvals(t *testing.T) {
+	var many []interface{}
+	for i := 0; i < 2*smallKV; i++ {
+		many = append(many, fmt.Sprintf("k%d", i%smallKV), i)
+	}
+	tests := []struct {
+		policy DupPolicy
+		in     []interface{}
+		want   string
+	}{
+		{DupLastWins, []interface{}{"a", 1, "b", 2}, "[a 1 b 2]"},
+		{DupLastWins, []interface{}{"a", 1, stringerKey("a"), 2}, "[a 2]"},
+		{DupFirstWins, []interface{}{"a", 1, stringerKey("a"), 2}, "[a 1]"},
+		{DupLastWins, many, "[k0 8 k1 9 k2 10 k3 11 k4 12 k5 13 k6 14 k7 15]"},
+		{DupFirstWins, many, "[k0 0 k1 1 k2 2 k3 3 k4 4 k5 5 k6 6 k7 7]"},
+		{DupKeepAll, many, fmt.Sprint(many)},
+	}
+	for i, test := range tests {
+		if got := fmt.Sprint(flatKeyvals(test.in, test.policy)); got != test.want {
+			t.Errorf("%d: got %s, want %s", i, got, test.want)
+		}
+	}
+}
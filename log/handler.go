@@ -31,12 +31,23 @@ func (h handlerFunc) Log(e Event) error {
 // FilterHandler lets a function evaluate whether to discard the Event or pass it on
 // to a next Handler
 func FilterHandler(fn func(e Event) bool, h Handler) Handler {
-	return HandlerFunc(func(e Event) error {
-		if fn(e) {
-			return h.Log(e)
-		}
-		return nil
-	})
+	return &filterHandler{fn: fn, h: h}
+}
+
+type filterHandler struct {
+	fn func(e Event) bool
+	h  Handler
+}
+
+func (f *filterHandler) Log(e Event) error {
+	if f.fn(e) {
+		return f.h.Log(e)
+	}
+	return nil
+}
+
+func (f *filterHandler) Chained() []interface{} {
+	return []interface{}{f.h}
 }
 
 // LvlFilterHandler discards events with a level above maxLvl
@@ -46,17 +57,44 @@ func LvlFilterHandler(maxLvl syslog.Priority, h Handler) Handler {
 	}, h)
 }
 
-// MultiHandler distributes the event to several Handlers
+// MultiHandler distributes the event to several Handlers.
+// If a Handler fails, the error is returned as a *HandlerError, if several fail
+// as a *MultiError. If some Handlers logged the event, the errors are returned
+// as a *MultiError with Logged set, and the event counts as logged.
 // if an error happen the last error is returned.
 func MultiHandler(hs ...Handler) Handler {
-	return HandlerFunc(func(e Event) error {
-		var maybe_err error
-		for _, h := range hs {
-			err := h.Log(e)
-			if err != nil {
-				maybe_err = err
-			}
+	m := make(multiHandler, len(hs))
+	copy(m, hs)
+	return m
+}
+
+type multiHandler []Handler
+
+func (m multiHandler) Log(e Event) error {
+	var errs []error
+	var logged bool
+	for _, h := range m {
+		err := h.Log(e)
+		if err != nil {
+			errs = append(errs, wrapHandlerError(h, err))
+		}
+		if err == nil || partlyLogged(err) {
+			logged = true
 		}
-		return maybe_err
-	})
+	}
+	switch {
+	case len(errs) == 0:
+		return nil
+	case len(errs) == 1 && !logged:
+		return errs[0]
+	}
+	return &MultiError{Errors: errs, Logged: logged}
+}
+
+func (m multiHandler) Chained() []interface{} {
+	c := make([]interface{}, len(m))
+	for i, h := range m {
+		c[i] = h
+	}
+	return c
 }
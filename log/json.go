@@ -11,45 +11,83 @@ import (
 type jsonformatter struct {
 	out      io.Writer
 	keynames *EventKeyNames // Names for basic event fields
+	dup      DupPolicy      // what to do with duplicate keys
 }
 
 // NewJSONFormatter creates a new formatting Handler writing log events as JSON to the supplied Writer.
-func NewJSONFormatter(w io.Writer) *jsonformatter {
-	return &jsonformatter{keynames: defaultKeyNames, out: w}
+// The only option supported is DupPolicyOpt()
+func NewJSONFormatter(w io.Writer, options ...HandlerOption) *jsonformatter {
+	f := &jsonformatter{keynames: defaultKeyNames, out: w}
+	for _, option := range options {
+		option(f)
+	}
+	return f
+}
+
+// Clone returns a clone of the formatter with the options applied
+func (l *jsonformatter) Clone(options ...HandlerOption) CloneableHandler {
+	new := &jsonformatter{}
+	*new = *l
+	for _, option := range options {
+		option(new)
+	}
+	return new
+}
+
+// Chained returns the Writer of the formatter, to let Shutdown() flush it.
+func (l *jsonformatter) Chained() []interface{} {
+	return []interface{}{l.out}
 }
 
 func (l *jsonformatter) Log(e Event) error {
-	x := len(e.Data)
-	n := x/2 + 3
+	data := e.ResolvedData()
+	x := len(data)
+	n := x/2 + 6
 	m := make(map[string]interface{}, n)
-	m[l.keynames.Lvl] = e.Lvl
+	m[l.keynames.Lvl] = levelNumber(e.Lvl) // syslog number (or 8-15), not the Priority name
 	m[l.keynames.Msg] = e.Msg
 	m[l.keynames.Time] = e.Time()
+	if e.fok {
+		m[l.keynames.File] = e.file
+		m[l.keynames.Line] = e.line
+		m[l.keynames.Func] = e.fn
+	}
+	if e.cat != 0 {
+		m[l.keynames.Cat] = e.Category()
+	}
+	if e.fac != 0 {
+		m[l.keynames.Fac] = int(e.fac >> 3) // syslog facility code
+	}
+	if e.goid != 0 {
+		m[l.keynames.Goid] = e.goid
+	}
+	if len(e.labels) > 1 {
+		labels := make(map[string]string, len(e.labels)/2)
+		for i := 0; i+1 < len(e.labels); i += 2 {
+			labels[e.labels[i]] = e.labels[i+1]
+		}
+		m[l.keynames.Labels] = labels
+	}
 	for i := 0; i < x; i += 2 {
-		k := e.Data[i]
+		k := data[i]
 		var v interface{} = errors.New("MISSING")
-		if i+1 < len(e.Data) {
-			v = e.Data[i+1]
+		if i+1 < len(data) {
+			v = data[i+1]
 		}
-		merge(m, k, v)
+		merge(m, k, v, l.dup)
 	}
 	return json.NewEncoder(l.out).Encode(m)
 }
 
-func merge(dst map[string]interface{}, k, v interface{}) {
-	var key string
-	switch x := k.(type) {
-	case string:
-		key = x
-	case fmt.Stringer:
-		key = safeString(x)
-	default:
-		key = fmt.Sprint(x)
+func merge(dst map[string]interface{}, k, v interface{}, policy DupPolicy) {
+	if g, ok := k.(KVGroup); ok {
+		mergeGroup(dst, g, policy)
+		return
 	}
 	if x, ok := v.(error); ok {
 		v = safeError(x)
 	}
-	dst[key] = v
+	putValue(dst, keyString(k), v, policy)
 }
 
 func safeString(str fmt.Stringer) (s string) {
@@ -16,20 +16,59 @@ func normalize(ctx []interface{}) []interface{} {
 	// if the caller passed a KV object, then expand it
 	if len(ctx) == 1 {
 		if ctxMap, ok := ctx[0].(KV); ok {
-			ctx = ctxMap.toArray()
+			return ctxMap.toArray()
 		}
 	}
 
+	// A LazyKV or KVGroup takes the place of a key/value pair. Give it an empty value.
+	// Find the size of the result first, to only copy ctx if needed.
+	n, copied, odd := 0, false, false
+	for i := 0; i < len(ctx); n += 2 {
+		switch ctx[i].(type) {
+		case LazyKV, KVGroup:
+			copied = true
+			i++
+			continue
+		}
+		if i+1 == len(ctx) {
+			odd = true
+		} else if _, ok := ctx[i+1].(KVGroup); ok {
+			copied = true
+		}
+		i += 2
+	}
+
 	// ctx needs to be even because it's a series of key/value pairs
 	// no one wants to check for errors on logging functions,
 	// so instead of erroring on bad input, we'll just make sure
 	// that things are the right length and users can fix bugs
 	// when they see the output looks wrong
-	if len(ctx)%2 != 0 {
-		ctx = append(ctx, nil, errorKey, "Normalized odd number of arguments by adding nil")
+	if !copied && !odd {
+		return ctx
+	}
+	if odd {
+		n += 2
+	}
+	out := make([]interface{}, 0, n)
+	for i := 0; i < len(ctx); {
+		switch ctx[i].(type) {
+		case LazyKV, KVGroup:
+			out = append(out, ctx[i], nil)
+			i++
+			continue
+		}
+		if i+1 == len(ctx) {
+			return append(out, ctx[i], nil, errorKey, "Normalized odd number of arguments by adding nil")
+		}
+		k, v := ctx[i], ctx[i+1]
+		if g, ok := v.(KVGroup); ok {
+			// A group given as a value is nested in a group named by the key.
+			k, v = KVGroup{Name: keyString(k), KV: []interface{}{g, nil}}, nil
+		}
+		out = append(out, k, v)
+		i += 2
 	}
-	//	fmt.Printf("%#v",ctx)
-	return ctx
+	return out
 }
 
 func (c KV) toArray() []interface{} {
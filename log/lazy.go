@@ -4,28 +4,99 @@ import (
 	"fmt"
 )
 
-// Lazy evaluation of values to log
-// returns stringable objects
+// Lazy evaluation of values to log.
+// The function is called at most once per event, when the event is formatted,
+// and the result is logged with its real type (so JSON gets numbers and objects).
+// A panic in the function is logged as an error value instead.
 type Lazy func() interface{}
 
-// bindValues replaces all value elements (odd indexes) containing a Valuer
-// with their generated value.
-func bindLazy(keyvals []interface{}) {
-	for i := 1; i < len(keyvals); i += 2 {
-		if v, ok := keyvals[i].(Lazy); ok {
-			keyvals[i] = v.evaluate()
+// LazyKV evaluates lazily to several key/value pairs. Pass it in place of a
+// key/value pair:
+//
+//	l.DEBUG("stats", log.LazyKV(func() []interface{} { return []interface{}{"hits", c.hits(), "misses", c.misses()} }))
+//
+// Like Lazy, it's evaluated at most once per event.
+type LazyKV func() []interface{}
+
+// evaluate calls the Lazy function, returning any panic as an error value.
+func (l Lazy) evaluate() (v interface{}) {
+	defer func() {
+		if p := recover(); p != nil {
+			v = fmt.Errorf("PANIC in Lazy: %v", p)
 		}
-	}
+	}()
+	return l()
+}
+
+func (l LazyKV) evaluate() (kv []interface{}) {
+	defer func() {
+		if p := recover(); p != nil {
+			kv = []interface{}{errorKey, fmt.Errorf("PANIC in LazyKV: %v", p)}
+		}
+	}()
+	return normalize(l())
 }
 
-func (l Lazy) evaluate() string {
-	v := l()
-	if s, ok := v.(string); ok {
-		return s
+// needsResolve tells whether keyvals need resolving.
+func needsResolve(keyvals []interface{}) bool {
+	for i := 0; i+1 < len(keyvals); i += 2 {
+		switch k := keyvals[i].(type) {
+		case LazyKV:
+			return true
+		case KVGroup:
+			if needsResolve(k.KV) {
+				return true
+			}
+		}
+		if _, ok := keyvals[i+1].(Lazy); ok || expandable(keyvals[i+1]) {
+			return true
+		}
 	}
-	if s, ok := v.(fmt.Stringer); ok {
-		return s.String()
+	return false
+}
+
+// resolveData returns a copy of keyvals with Lazy values evaluated, LazyKV
+// expanded, LogMarshalers made into groups and error fields and causes added.
+func resolveData(keyvals []interface{}, depth int) []interface{} {
+	out := make([]interface{}, 0, len(keyvals))
+	for i := 0; i+1 < len(keyvals); i += 2 {
+		k, v := keyvals[i], keyvals[i+1]
+		switch x := k.(type) {
+		case LazyKV:
+			kv := x.evaluate()
+			if depth < maxMarshalDepth && needsResolve(kv) {
+				kv = resolveData(kv, depth+1)
+			}
+			out = append(out, kv...)
+			continue
+		case KVGroup:
+			if needsResolve(x.KV) {
+				k = KVGroup{Name: x.Name, KV: resolveData(x.KV, depth)}
+			}
+		}
+		if l, ok := v.(Lazy); ok {
+			v = l.evaluate()
+		}
+		if expandable(v) {
+			out = expand(out, k, v, depth)
+			continue
+		}
+		out = append(out, k, v)
 	}
-	return fmt.Sprintln(v)
+	return out
+}
 
+// ResolvedData returns the event key/value data with Lazy values evaluated, LazyKV
+// expanded, LogMarshaler values expanded into groups and the fields and causes of errors
+// added next to them (see LogMarshaler). The evaluation is done once per event and shared by all Handlers.
+// Use it instead of Data in Handlers needing the actual values.
+func (e Event) ResolvedData() []interface{} {
+	if !e.rok {
+		e.resolved = e.Data
+		if needsResolve(e.Data) {
+			e.resolved = resolveData(e.Data, 0)
+		}
+		e.rok = true
+	}
+	return e.resolved
 }
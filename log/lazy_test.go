@@ -0,0 +1,83 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/One-com/gonelog/syslog"
+	"reflect"
+	"testing"
+)
+
+func TestLazyOncePerEvent(t *testing.T) {
+	var std, js bytes.Buffer
+	l := NewLogger(syslog.LOG_INFO, MultiHandler(
+		NewStdFormatter(&std, "", Llevel|Lname),
+		NewJSONFormatter(&js)))
+
+	calls := 0
+	l.INFO("lazy",
+		"n", Lazy(func() interface{} { calls++; return 42 }),
+		"obj", Lazy(func() interface{} { return map[string]int{"a": 1} }),
+		LazyKV(func() []interface{} { return []interface{}{"x", 1.5, "y", true} }),
+		"bad", Lazy(func() interface{} { panic("oops") }))
+
+	if calls != 1 {
+		t.Errorf("Lazy evaluated %d times", calls)
+	}
+	want := `<6> () lazy n=42 obj="unsupported value type" x=1.5 y=true bad="PANIC in Lazy: oops"` + "\n"
+	if std.String() != want {
+		t.Errorf("got %q, want %q", std.String(), want)
+	}
+
+	var m map[string]interface{}
+	if err := json.Unmarshal(js.Bytes(), &m); err != nil {
+		t.Fatal(err)
+	}
+	if m["n"] != 42.0 || m["x"] != 1.5 || m["y"] != true || m["bad"] != "PANIC in Lazy: oops" {
+		t.Errorf("wrong JSON values: %v", m)
+	}
+	if obj, ok := m["obj"].(map[string]interface{}); !ok || obj["a"] != 1.0 {
+		t.Errorf("wrong JSON object: %v", m["obj"])
+	}
+}
+
+func TestNormalizeLazyKV(t *testing.T) {
+	lkv := LazyKV(func() []interface{} { return []interface{}{"a"} })
+	kv := normalize([]interface{}{lkv, "b", 2})
+	if len(kv) != 4 || kv[1] != nil || kv[2] != "b" {
+		t.Errorf("LazyKV not given a value: %v", kv)
+	}
+	r := resolveData(kv, 0)
+	if len(r) != 6 || r[0] != "a" || r[1] != nil || r[2] != errorKey || r[4] != "b" {
+		t.Errorf("LazyKV not expanded and normalized: %v", r)
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	g := Group("req", "a", 1)
+	tests := []struct {
+		in, want []interface{}
+	}{
+		{[]interface{}{"a", 1}, []interface{}{"a", 1}},
+		{[]interface{}{g, "b", 2}, []interface{}{g, nil, "b", 2}},
+		{[]interface{}{"b", 2, g}, []interface{}{"b", 2, g, nil}},
+		{[]interface{}{"a", 1, "b"}, []interface{}{"a", 1, "b", nil, errorKey, "Normalized odd number of arguments by adding nil"}},
+		{[]interface{}{"http", g}, []interface{}{KVGroup{Name: "http", KV: []interface{}{g, nil}}, nil}},
+	}
+	for _, test := range tests {
+		got := normalize(test.in)
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("normalize(%v) = %v, want %v", test.in, got, test.want)
+		}
+		if cap(got) != len(got) {
+			t.Errorf("normalize(%v): cap %d, len %d", test.in, cap(got), len(got))
+		}
+	}
+
+	var buf bytes.Buffer
+	l := NewLogger(syslog.LOG_INFO, NewStdFormatter(&buf, "", 0))
+	l.INFO("x", "http", g)
+	if want := "x http.req.a=1\n"; buf.String() != want {
+		t.Errorf("got %q, want %q", buf.String(), want)
+	}
+}
@@ -0,0 +1,194 @@
+package log
+
+import (
+	"fmt"
+	"github.com/One-com/gonelog/syslog"
+	"strconv"
+	"strings"
+)
+
+// Besides the 8 syslog severities, up to 8 additional levels below LOG_DEBUG
+// (numbered 8-15) can be registered. LvlTRACE is registered by default.
+// The additional levels are encoded as their number << 8 (see ExtLevel()), outside the
+// syslog facility<<3|severity range, so they're never mistaken for a priority with a facility.
+const LvlTRACE syslog.Priority = 8 << extShift
+
+const (
+	extShift                 = 8
+	extMask  syslog.Priority = 0xf00
+)
+
+// Number of levels supported by the Logger config.
+const numLevels = 16
+
+// ExtLevel returns the log level for additional level number n (8-15)
+func ExtLevel(n int) syslog.Priority {
+	return syslog.Priority(n) << extShift
+}
+
+// LevelInfo describes an additional log level below LOG_DEBUG
+type LevelInfo struct {
+	Name   string          // Name used when parsing and printing the level: "trace"
+	Prefix string          // Prefix for terminal output (like term_lvlpfx): "[TRC]"
+	Color  string          // ANSI SGR color code for terminal output: "37;2"
+	Syslog syslog.Priority // Severity used for outputs only knowing the syslog levels 0-7.
+}
+
+// The registered extra levels. Indexed by level number-8.
+// Only written during init, so no locking.
+var extraLevels [numLevels - 8]*LevelInfo
+
+// The highest (least severe) registered level. Loggers can't go above it.
+var maxLevel = syslog.LOG_DEBUG
+
+func init() {
+	RegisterLevel(LvlTRACE, LevelInfo{Name: "trace", Prefix: "[TRC]", Color: "37;2", Syslog: syslog.LOG_DEBUG})
+}
+
+// RegisterLevel registers an additional log level below LOG_DEBUG (ExtLevel(8) - ExtLevel(15)).
+// It has to be called during package initialization, before any logging is done.
+// Use Logger.Log() to log at the level.
+func RegisterLevel(lvl syslog.Priority, info LevelInfo) error {
+	n := levelNumber(lvl)
+	if n <= int(syslog.LOG_DEBUG) || lvl != ExtLevel(n) {
+		return fmt.Errorf("log: level %d is not an additional level ExtLevel(%d-%d)", lvl, syslog.LOG_DEBUG+1, numLevels-1)
+	}
+	if info.Syslog < syslog.LOG_EMERG || info.Syslog > syslog.LOG_DEBUG {
+		return fmt.Errorf("log: level %d maps to invalid syslog severity %d", lvl, info.Syslog)
+	}
+	i := info
+	i.Name = strings.ToLower(i.Name)
+	extraLevels[n-8] = &i
+	if lvl > maxLevel {
+		maxLevel = lvl
+	}
+	return nil
+}
+
+// levelNumber returns the number (0-15) of a level, ignoring any facility.
+// Levels out of range are limited to 0 and 15.
+func levelNumber(lvl syslog.Priority) int {
+	switch {
+	case lvl < 0:
+		return 0
+	case lvl > extMask|0xff:
+		return numLevels - 1
+	case lvl&extMask != 0:
+		if n := int(lvl >> extShift); n > int(syslog.LOG_DEBUG) {
+			return n
+		}
+		return int(syslog.LOG_DEBUG) + 1
+	}
+	return int(lvl.Severity())
+}
+
+// numberLevel returns the level with number n (0-15)
+func numberLevel(n int) syslog.Priority {
+	if n > int(syslog.LOG_DEBUG) {
+		return ExtLevel(n)
+	}
+	return syslog.Priority(n)
+}
+
+// severity returns the level of a priority without any facility
+func severity(lvl syslog.Priority) syslog.Priority {
+	if lvl >= syslog.LOG_EMERG && lvl <= syslog.LOG_DEBUG {
+		return lvl
+	}
+	return numberLevel(levelNumber(lvl))
+}
+
+func levelInfo(lvl syslog.Priority) *LevelInfo {
+	if n := levelNumber(lvl); n > int(syslog.LOG_DEBUG) {
+		return extraLevels[n-8]
+	}
+	return nil
+}
+
+// SyslogLevel maps a log level to one of the 8 syslog severities.
+// Syslog levels are returned unchanged, additional levels are mapped as registered.
+// Any facility is removed. Unknown levels are mapped to LOG_DEBUG.
+func SyslogLevel(lvl syslog.Priority) syslog.Priority {
+	n := levelNumber(lvl)
+	if n <= int(syslog.LOG_DEBUG) {
+		return syslog.Priority(n)
+	}
+	if info := extraLevels[n-8]; info != nil {
+		return info.Syslog
+	}
+	return syslog.LOG_DEBUG
+}
+
+// LevelName returns the name of a log level, including registered levels.
+func LevelName(lvl syslog.Priority) string {
+	if info := levelInfo(lvl); info != nil {
+		return info.Name
+	}
+	return lvl.String()
+}
+
+// ParseLevel parses a log level like syslog.ParsePriority, but also
+// accepting the names and numbers (8-15) of registered levels.
+// Any facility ("local0.err") is ignored.
+func ParseLevel(s string) (syslog.Priority, error) {
+	name := strings.ToLower(strings.TrimSpace(s))
+	for i, info := range extraLevels {
+		if info != nil && info.Name == name {
+			return ExtLevel(i + 8), nil
+		}
+	}
+	lvl, err := syslog.ParsePriority(s)
+	if err != nil {
+		return 0, err
+	}
+	if n, err := strconv.Atoi(name); err == nil && n > int(syslog.LOG_DEBUG) && n < numLevels {
+		lvl = ExtLevel(n)
+	}
+	if !validLevel(lvl) {
+		return 0, fmt.Errorf("log: invalid level %q", s)
+	}
+	return severity(lvl), nil
+}
+
+// validLevel tells whether lvl is a syslog priority or a registered level,
+// possibly with a facility.
+func validLevel(lvl syslog.Priority) bool {
+	if lvl >= syslog.LOG_EMERG && lvl <= syslog.LOG_LOCAL7|syslog.LOG_DEBUG {
+		return true
+	}
+	return lvl&^(extMask|lvl.Facility()) == 0 && lvl>>extShift > syslog.LOG_DEBUG && levelInfo(lvl) != nil
+}
+
+// clampLevel limits a level to the registered levels, ignoring any facility.
+func clampLevel(lvl syslog.Priority) syslog.Priority {
+	lvl = severity(lvl)
+	if lvl > maxLevel {
+		return maxLevel
+	}
+	return lvl
+}
+
+// Level color and prefix for the stdformatter.
+// Additional levels use their registered prefix with term_lvlpfx and their
+// syslog mapping for any other prefix array.
+func levelColor(lvl syslog.Priority) string {
+	if lvl >= syslog.LOG_EMERG && lvl <= syslog.LOG_DEBUG {
+		return level_colors[lvl]
+	}
+	if info := levelInfo(lvl); info != nil && info.Color != "" {
+		return info.Color
+	}
+	return level_colors[syslog.LOG_DEBUG]
+}
+
+func levelPrefix(pfxarr *[8]string, lvl syslog.Priority) string {
+	if lvl >= syslog.LOG_EMERG && lvl <= syslog.LOG_DEBUG {
+		return pfxarr[lvl]
+	}
+	if pfxarr == &term_lvlpfx {
+		if info := levelInfo(lvl); info != nil && info.Prefix != "" {
+			return info.Prefix
+		}
+	}
+	return pfxarr[SyslogLevel(lvl)]
+}
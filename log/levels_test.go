@@ -0,0 +1,136 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/One-com/gonelog/syslog"
+	"strconv"
+	"testing"
+)
+
+func TestTraceLevel(t *testing.T) {
+	var b bytes.Buffer
+	l := NewLogger(syslog.LOG_DEBUG, NewMinFormatter(&b))
+	l.TRACE("hidden")
+	if _, ok := l.TRACEok(); ok {
+		t.Error("TRACEok at debug level")
+	}
+	if !l.IncLevel() || l.Level() != LvlTRACE {
+		t.Errorf("IncLevel to trace: %d", l.Level())
+	}
+	l.TRACE("min")
+	l.ApplyHandlerOptions(FlagsOpt(Llevel|Lname), LevelPrefixOpt(&term_lvlpfx))
+	l.TRACE("term")
+	l.ApplyHandlerOptions(FlagsOpt(Llevel|Lcolor), LevelPrefixOpt(&syslog_lvlpfx), FacilityOpt(syslog.LOG_LOCAL0))
+	if f, ok := l.TRACEok(); ok {
+		f("color")
+	}
+	want := "<7>min\n[TRC] () term\n\x1b[37;2m<135>\x1b[0mcolor\n"
+	if b.String() != want {
+		t.Errorf("got %q, want %q", b.String(), want)
+	}
+
+	l.SetLevel(ExtLevel(15))
+	if l.Level() != maxLevel {
+		t.Errorf("level not clamped: %d", l.Level())
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	for s, want := range map[string]syslog.Priority{"TRACE": LvlTRACE, "8": LvlTRACE, "2048": LvlTRACE,
+		"dbg": syslog.LOG_DEBUG, "local0.err": syslog.LOG_ERR, "131": syslog.LOG_ERR} {
+		if lvl, err := ParseLevel(s); err != nil || lvl != want {
+			t.Errorf("ParseLevel(%q) = %d, %v", s, lvl, err)
+		}
+	}
+	for _, s := range []string{"15", "3840", "300", "-1", "192"} {
+		if _, err := ParseLevel(s); err == nil {
+			t.Errorf("invalid level %q parsed", s)
+		}
+	}
+	if LevelName(LvlTRACE) != "trace" || LevelName(syslog.LOG_ERR) != "err" {
+		t.Error("bad level names")
+	}
+	for _, lvl := range []syslog.Priority{syslog.LOG_DEBUG, 9, syslog.LOG_USER | syslog.LOG_ERR, ExtLevel(16), ExtLevel(9) | 1} {
+		if err := RegisterLevel(lvl, LevelInfo{Name: "x"}); err == nil {
+			t.Errorf("registered invalid level %d", lvl)
+		}
+	}
+}
+
+func TestLevelRoundTrip(t *testing.T) {
+	// Register all the custom levels for the test
+	saved, savedMax := extraLevels, maxLevel
+	defer func() { extraLevels, maxLevel = saved, savedMax }()
+	levels := []syslog.Priority{LvlTRACE}
+	for n := 9; n < numLevels; n++ {
+		lvl := ExtLevel(n)
+		if err := RegisterLevel(lvl, LevelInfo{Name: "custom" + strconv.Itoa(n), Syslog: syslog.LOG_DEBUG}); err != nil {
+			t.Fatal(err)
+		}
+		levels = append(levels, lvl)
+	}
+
+	var b bytes.Buffer
+	l := NewLogger(ExtLevel(15), NewJSONFormatter(&b))
+	for _, lvl := range levels {
+		text, _ := lvl.MarshalText()
+		var p syslog.Priority
+		if err := p.UnmarshalText(text); err != nil || p != lvl {
+			t.Errorf("text %d: %q -> %d %v", lvl, text, p, err)
+		}
+		js, _ := json.Marshal(lvl)
+		if err := json.Unmarshal(js, &p); err != nil || p != lvl {
+			t.Errorf("JSON %d: %s -> %d %v", lvl, js, p, err)
+		}
+		if p, err := ParseLevel(LevelName(lvl)); err != nil || p != lvl {
+			t.Errorf("name %d: %q -> %d %v", lvl, LevelName(lvl), p, err)
+		}
+		if lvl.Facility() != 0 || SyslogLevel(lvl) != syslog.LOG_DEBUG {
+			t.Errorf("level %d mistaken for a facility", lvl)
+		}
+
+		b.Reset()
+		l.SetLevel(lvl)
+		if l.Level() != lvl {
+			t.Errorf("SetLevel %d: %d", lvl, l.Level())
+		}
+		l.Log(lvl, "x")
+		var m map[string]interface{}
+		if err := json.Unmarshal(b.Bytes(), &m); err != nil {
+			t.Fatal(err)
+		}
+		if p, err := ParseLevel(fmt.Sprint(m["_lvl"])); err != nil || p != lvl {
+			t.Errorf("JSON level number %d: %v -> %d %v", lvl, m["_lvl"], p, err)
+		}
+	}
+
+	// severity 3 with the user facility is not a custom level
+	l.SetLevel(syslog.LOG_INFO)
+	b.Reset()
+	l.Log(syslog.LOG_USER|syslog.LOG_ERR, "user.err")
+	if b.Len() == 0 {
+		t.Error("user.err filtered")
+	}
+}
+
+func TestFacilityLevels(t *testing.T) {
+	var b bytes.Buffer
+	rec := &eventRecorder{}
+	l := NewLogger(syslog.LOG_INFO, MultiHandler(NewMinFormatter(&b), rec))
+
+	l.Log(syslog.LOG_LOCAL0|syslog.LOG_ERR, "local0")
+	l.Log(syslog.LOG_LOCAL0|syslog.LOG_DEBUG, "filtered")
+	l.WithFacility(syslog.LOG_DAEMON).WARN("daemon")
+	l.WithFacility(syslog.LOG_DAEMON).Log(syslog.LOG_LOCAL1|syslog.LOG_NOTICE, "local1")
+	l.ForceLevel(syslog.LOG_DEBUG).Log(syslog.LOG_LOCAL0|syslog.LOG_DEBUG, "forced")
+
+	want := "<131>local0\n<28>daemon\n<141>local1\n<135>forced\n"
+	if b.String() != want {
+		t.Errorf("got %q, want %q", b.String(), want)
+	}
+	if e := rec.events[0]; e.Lvl != syslog.LOG_ERR || e.Facility() != syslog.LOG_LOCAL0 {
+		t.Errorf("wrong event level %d, facility %d", e.Lvl, e.Facility())
+	}
+}
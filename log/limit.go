@@ -0,0 +1,333 @@
+package log
+
+import (
+	ilog "github.com/One-com/gonelog"
+	"github.com/One-com/gonelog/syslog"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// State of a rate limited log statement.
+// For Every() next is the time (unix nano) the next event is allowed.
+// For FirstN() count is the number of events generated so far.
+type limitState struct {
+	every time.Duration
+	n     int64
+	next  int64
+	count int64
+}
+
+// open tells whether an event would be allowed now, without taking it.
+func (s *limitState) open(c Clock) bool {
+	if s.every > 0 {
+		return c.Now().UnixNano() >= atomic.LoadInt64(&s.next)
+	}
+	return atomic.LoadInt64(&s.count) < s.n
+}
+
+// take allows a single event if possible.
+func (s *limitState) take(c Clock) bool {
+	if s.every > 0 {
+		now := c.Now().UnixNano()
+		for {
+			next := atomic.LoadInt64(&s.next)
+			if now < next {
+				return false
+			}
+			if atomic.CompareAndSwapInt64(&s.next, next, now+int64(s.every)) {
+				return true
+			}
+		}
+	}
+	for {
+		count := atomic.LoadInt64(&s.count)
+		if count >= s.n {
+			return false
+		}
+		if atomic.CompareAndSwapInt64(&s.count, count, count+1) {
+			return true
+		}
+	}
+}
+
+// Limit state is looked up by Logger config, call site/key and kind of limit.
+type limitKey struct {
+	cfg   *lconfig
+	pc    uintptr
+	key   string
+	every time.Duration
+	n     int64
+}
+
+// Limit states are never removed.
+var (
+	limitStatesMu sync.RWMutex
+	limitStates   = make(map[limitKey]*limitState)
+)
+
+func getLimitState(k limitKey) *limitState {
+	limitStatesMu.RLock()
+	s, ok := limitStates[k]
+	limitStatesMu.RUnlock()
+	if ok {
+		return s
+	}
+	limitStatesMu.Lock()
+	defer limitStatesMu.Unlock()
+	if s, ok := limitStates[k]; ok {
+		return s
+	}
+	s = &limitState{every: k.every, n: k.n}
+	limitStates[k] = s
+	return s
+}
+
+func callerPC(skip int) uintptr {
+	var pcs [1]uintptr
+	runtime.Callers(skip+2, pcs[:])
+	return pcs[0]
+}
+
+// Every returns a LevelLogger generating at most one event per interval d from
+// the calling code line. The first event is generated right away.
+//
+//	l.Every(time.Minute).WARN("queue full", "len", n)
+//
+// The state is kept per call site and Logger config (shared with children from With())
+// and lives as long as the program, so don't use it with an unbounded number of Loggers
+// from NewLogger().
+// Nothing is looked up or allocated for events at levels the Logger doesn't do.
+func (l *Logger) Every(d time.Duration) ilog.LevelLogger {
+	return l.limited(limitParams{site: true, every: d})
+}
+
+// EveryKey is like Every(), but keyed by key instead of the call site,
+// so several log statements can share the limit.
+// The state of each key lives as long as the program, so keys must come from a bounded set.
+func (l *Logger) EveryKey(key string, d time.Duration) ilog.LevelLogger {
+	return l.limited(limitParams{key: key, every: d})
+}
+
+// FirstN returns a LevelLogger generating only the first n events from the calling code line.
+func (l *Logger) FirstN(n int) ilog.LevelLogger {
+	return l.limited(limitParams{site: true, n: int64(n)})
+}
+
+// FirstNKey is like FirstN(), but keyed by key instead of the call site.
+// Like for EveryKey(), keys must come from a bounded set.
+func (l *Logger) FirstNKey(key string, n int) ilog.LevelLogger {
+	return l.limited(limitParams{key: key, n: int64(n)})
+}
+
+// Once returns a LevelLogger generating only a single event for the key.
+// With an empty key, the call site is used as key.
+// Like for EveryKey(), keys must come from a bounded set.
+//
+//	l.Once("deprecated-config").WARN("config option foo is deprecated")
+func (l *Logger) Once(key string) ilog.LevelLogger {
+	return l.limited(limitParams{site: key == "", key: key, n: 1})
+}
+
+// limitParams is a kind of limit
+type limitParams struct {
+	site  bool // by call site
+	key   string
+	every time.Duration
+	n     int64
+}
+
+// Number of limitedLoggers cached per Logger
+const maxCachedLimits = 16
+
+// limited returns a LevelLogger for the limit. The LevelLoggers are cached per Logger,
+// to not allocate for each log statement.
+func (l *Logger) limited(p limitParams) ilog.LevelLogger {
+	if p.every <= 0 && p.n <= 0 {
+		return nopLogger{}
+	}
+	cache, _ := l.limits.Load().(map[limitParams]*limitedLogger)
+	if ll, ok := cache[p]; ok {
+		return ll
+	}
+	ll := &limitedLogger{l: l, p: p}
+	if len(cache) < maxCachedLimits {
+		limitmu.Lock()
+		cache, _ = l.limits.Load().(map[limitParams]*limitedLogger)
+		if len(cache) < maxCachedLimits {
+			c := make(map[limitParams]*limitedLogger, len(cache)+1)
+			for k, v := range cache {
+				c[k] = v
+			}
+			c[p] = ll
+			l.limits.Store(c)
+		}
+		limitmu.Unlock()
+	}
+	return ll
+}
+
+var limitmu sync.Mutex // serializing limit cache inserts
+
+// limitedLogger only generates an event if the Logger does the level and the
+// limit allows it. The limit is only used up by events actually generated.
+// The limit state (and call site) is only looked up for levels the Logger does.
+type limitedLogger struct {
+	l *Logger
+	p limitParams
+}
+
+// state finds the limit state. skip is the number of frames between the call
+// site and the caller of state().
+func (ll *limitedLogger) state(skip int) *limitState {
+	k := limitKey{cfg: ll.l.cfg, key: ll.p.key, every: ll.p.every, n: ll.p.n}
+	if ll.p.site {
+		k.pc = callerPC(skip + 1)
+	}
+	return getLimitState(k)
+}
+
+func (ll *limitedLogger) ok(level syslog.Priority) bool {
+	return ll.l.Does(level) && ll.state(2).take(ll.l.cfg.getClock())
+}
+
+func (ll *limitedLogger) Does(level syslog.Priority) bool {
+	return ll.l.Does(level) && ll.state(1).open(ll.l.cfg.getClock())
+}
+
+func (ll *limitedLogger) ALERT(msg string, kv ...interface{}) {
+	lvl := syslog.LOG_ALERT
+	if ll.ok(lvl) {
+		ll.l.log(lvl, msg, kv...)
+	}
+}
+
+func (ll *limitedLogger) CRIT(msg string, kv ...interface{}) {
+	lvl := syslog.LOG_CRIT
+	if ll.ok(lvl) {
+		ll.l.log(lvl, msg, kv...)
+	}
+}
+
+func (ll *limitedLogger) ERROR(msg string, kv ...interface{}) {
+	lvl := syslog.LOG_ERROR
+	if ll.ok(lvl) {
+		ll.l.log(lvl, msg, kv...)
+	}
+}
+
+func (ll *limitedLogger) WARN(msg string, kv ...interface{}) {
+	lvl := syslog.LOG_WARN
+	if ll.ok(lvl) {
+		ll.l.log(lvl, msg, kv...)
+	}
+}
+
+func (ll *limitedLogger) NOTICE(msg string, kv ...interface{}) {
+	lvl := syslog.LOG_NOTICE
+	if ll.ok(lvl) {
+		ll.l.log(lvl, msg, kv...)
+	}
+}
+
+func (ll *limitedLogger) INFO(msg string, kv ...interface{}) {
+	lvl := syslog.LOG_INFO
+	if ll.ok(lvl) {
+		ll.l.log(lvl, msg, kv...)
+	}
+}
+
+func (ll *limitedLogger) DEBUG(msg string, kv ...interface{}) {
+	lvl := syslog.LOG_DEBUG
+	if ll.ok(lvl) {
+		ll.l.log(lvl, msg, kv...)
+	}
+}
+
+func (ll *limitedLogger) TRACE(msg string, kv ...interface{}) {
+	lvl := LvlTRACE
+	if ll.ok(lvl) {
+		ll.l.log(lvl, msg, kv...)
+	}
+}
+
+// The *ok() methods use up the limit if returning true, expecting the function to be called.
+func (ll *limitedLogger) ALERTok() (ilog.LogFunc, bool) {
+	if !ll.ok(syslog.LOG_ALERT) {
+		return nopLog, false
+	}
+	return ll.l.alert, true
+}
+
+func (ll *limitedLogger) CRITok() (ilog.LogFunc, bool) {
+	if !ll.ok(syslog.LOG_CRIT) {
+		return nopLog, false
+	}
+	return ll.l.crit, true
+}
+
+func (ll *limitedLogger) ERRORok() (ilog.LogFunc, bool) {
+	if !ll.ok(syslog.LOG_ERROR) {
+		return nopLog, false
+	}
+	return ll.l.error, true
+}
+
+func (ll *limitedLogger) WARNok() (ilog.LogFunc, bool) {
+	if !ll.ok(syslog.LOG_WARN) {
+		return nopLog, false
+	}
+	return ll.l.warn, true
+}
+
+func (ll *limitedLogger) NOTICEok() (ilog.LogFunc, bool) {
+	if !ll.ok(syslog.LOG_NOTICE) {
+		return nopLog, false
+	}
+	return ll.l.notice, true
+}
+
+func (ll *limitedLogger) INFOok() (ilog.LogFunc, bool) {
+	if !ll.ok(syslog.LOG_INFO) {
+		return nopLog, false
+	}
+	return ll.l.info, true
+}
+
+func (ll *limitedLogger) DEBUGok() (ilog.LogFunc, bool) {
+	if !ll.ok(syslog.LOG_DEBUG) {
+		return nopLog, false
+	}
+	return ll.l.debug, true
+}
+
+func (ll *limitedLogger) TRACEok() (ilog.LogFunc, bool) {
+	if !ll.ok(LvlTRACE) {
+		return nopLog, false
+	}
+	return ll.l.trace, true
+}
+
+// nopLogger is a LevelLogger generating nothing
+type nopLogger struct{}
+
+func nopLog(msg string, kv ...interface{}) {}
+
+func (nopLogger) Does(level syslog.Priority) bool      { return false }
+func (nopLogger) ALERT(msg string, kv ...interface{})  {}
+func (nopLogger) CRIT(msg string, kv ...interface{})   {}
+func (nopLogger) ERROR(msg string, kv ...interface{})  {}
+func (nopLogger) WARN(msg string, kv ...interface{})   {}
+func (nopLogger) NOTICE(msg string, kv ...interface{}) {}
+func (nopLogger) INFO(msg string, kv ...interface{})   {}
+func (nopLogger) DEBUG(msg string, kv ...interface{})  {}
+func (nopLogger) TRACE(msg string, kv ...interface{})  {}
+func (nopLogger) ALERTok() (ilog.LogFunc, bool)        { return nopLog, false }
+func (nopLogger) CRITok() (ilog.LogFunc, bool)         { return nopLog, false }
+func (nopLogger) ERRORok() (ilog.LogFunc, bool)        { return nopLog, false }
+func (nopLogger) WARNok() (ilog.LogFunc, bool)         { return nopLog, false }
+func (nopLogger) NOTICEok() (ilog.LogFunc, bool)       { return nopLog, false }
+func (nopLogger) INFOok() (ilog.LogFunc, bool)         { return nopLog, false }
+func (nopLogger) DEBUGok() (ilog.LogFunc, bool)        { return nopLog, false }
+func (nopLogger) TRACEok() (ilog.LogFunc, bool)        { return nopLog, false }
@@ -0,0 +1,83 @@
+package log
+
+import (
+	"github.com/One-com/gonelog/syslog"
+	"testing"
+	"time"
+)
+
+func TestEvery(t *testing.T) {
+	rec := &eventRecorder{}
+	l := NewLogger(syslog.LOG_INFO, rec)
+	clock := NewFakeClock(time.Unix(1000, 0))
+	l.SetClock(clock)
+
+	for i := 0; i < 10; i++ {
+		l.Every(time.Minute).WARN("every", "i", i)
+		l.Every(time.Minute).DEBUG("not enabled") // must not use the limit of others
+		clock.Add(10 * time.Second)
+	}
+	if len(rec.events) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(rec.events))
+	}
+	if rec.data[0][1] != 0 || rec.data[1][1] != 6 {
+		t.Errorf("wrong events generated: %v, %v", rec.data[0], rec.data[1])
+	}
+}
+
+func TestFirstNAndOnce(t *testing.T) {
+	rec := &eventRecorder{}
+	l := NewLogger(syslog.LOG_DEBUG, rec)
+
+	for i := 0; i < 10; i++ {
+		l.FirstN(3).INFO("first")
+		if f, ok := l.Once("").DEBUGok(); ok {
+			f("once")
+		}
+		l.With("i", i).Once("key").ERROR("once by key")
+		l.Once("key").ERROR("once by key")
+	}
+	count := make(map[string]int)
+	for _, e := range rec.events {
+		count[e.Msg]++
+	}
+	if count["first"] != 3 || count["once"] != 1 || count["once by key"] != 1 {
+		t.Errorf("wrong number of events: %v", count)
+	}
+}
+
+func TestLimitSuppressedNoAlloc(t *testing.T) {
+	l := NewLogger(syslog.LOG_INFO, &eventRecorder{})
+	l.Once("noalloc").INFO("first")
+	allocs := testing.AllocsPerRun(100, func() {
+		if f, ok := l.Once("noalloc").INFOok(); ok {
+			f("again")
+		}
+		l.Every(time.Hour).INFO("every")
+	})
+	if allocs != 0 {
+		t.Errorf("suppressed logging allocates: %v", allocs)
+	}
+}
+
+func TestLimitLevelDisabledNoAlloc(t *testing.T) {
+	l := NewLogger(syslog.LOG_INFO, &eventRecorder{})
+	limitStatesMu.RLock()
+	states := len(limitStates)
+	limitStatesMu.RUnlock()
+	allocs := testing.AllocsPerRun(100, func() {
+		l.Every(time.Hour).DEBUG("disabled")
+		if f, ok := l.FirstN(1).DEBUGok(); ok {
+			f("disabled")
+		}
+	})
+	// Only the first call of each allocates a cached LevelLogger
+	if allocs != 0 {
+		t.Errorf("level disabled logging allocates: %v", allocs)
+	}
+	limitStatesMu.RLock()
+	defer limitStatesMu.RUnlock()
+	if len(limitStates) != states {
+		t.Errorf("limit state created for disabled level")
+	}
+}
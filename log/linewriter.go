@@ -0,0 +1,250 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/One-com/gonelog/syslog"
+	"io"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+// DefaultMaxLineLength is the default cap on the length of lines from a LineWriter.
+const DefaultMaxLineLength = 64 * 1024
+
+type lineWriter struct {
+	mu      sync.Mutex
+	l       *Logger
+	level   syslog.Priority
+	kv      []interface{}
+	max     int
+	raw     bool
+	buf     []byte
+	discard bool // skipping the rest of a too long line
+	closed  bool
+}
+
+// LineWriterOption configures a LineWriter
+type LineWriterOption func(*lineWriter)
+
+// LineLevel sets the level of lines not telling their level themselves. Default is LvlDEFAULT.
+func LineLevel(level syslog.Priority) LineWriterOption {
+	return func(w *lineWriter) {
+		w.level = level
+	}
+}
+
+// LineKV adds key/value data to all events, like "stream", "stderr".
+func LineKV(kv ...interface{}) LineWriterOption {
+	return func(w *lineWriter) {
+		w.kv = append(w.kv, normalize(kv)...)
+	}
+}
+
+// LineMaxLength caps the length of lines. The rest of a longer line is dropped,
+// and the event gets the key "truncated" set to true.
+func LineMaxLength(n int) LineWriterOption {
+	return func(w *lineWriter) {
+		w.max = n
+	}
+}
+
+// LineRaw turns off parsing of JSON and logfmt lines. Lines are used as
+// the event message as they are (except for any "<N>" level prefix)
+func LineRaw() LineWriterOption {
+	return func(w *lineWriter) {
+		w.raw = true
+	}
+}
+
+// NewLineWriter returns an io.WriteCloser generating an event on the Logger
+// for every line written to it, like the output of a child process:
+//
+//	cmd.Stderr = log.NewLineWriter(l, log.LineLevel(syslog.LOG_WARN), log.LineKV("stream", "stderr"))
+//
+// Partial lines are buffered until completed or the writer is closed.
+// A line starting with a sd-daemon "<N>" prefix is logged with syslog severity N.
+// JSON object lines and logfmt lines (all key=value) have their fields lifted into
+// the event key/value data. Their "msg"/"message" becomes the event message and
+// "level"/"lvl"/"severity" the level.
+// Events are only generated for levels the Logger does.
+func NewLineWriter(l *Logger, options ...LineWriterOption) io.WriteCloser {
+	w := &lineWriter{
+		l:     l,
+		level: LvlDEFAULT,
+		max:   DefaultMaxLineLength,
+	}
+	for _, option := range options {
+		option(w)
+	}
+	return w
+}
+
+func (w *lineWriter) Write(p []byte) (n int, err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return 0, io.ErrClosedPipe
+	}
+	n = len(p)
+	for len(p) > 0 {
+		i := bytes.IndexByte(p, '\n')
+		var chunk []byte
+		if i < 0 {
+			chunk, p = p, nil
+		} else {
+			chunk, p = p[:i], p[i+1:]
+		}
+		if !w.discard {
+			w.buf = append(w.buf, chunk...)
+			if w.max > 0 && len(w.buf) > w.max {
+				w.emit(w.buf[:w.max], true)
+				w.buf = w.buf[:0]
+				w.discard = true
+			}
+		}
+		if i >= 0 {
+			if !w.discard {
+				w.emit(w.buf, false)
+			}
+			w.buf = w.buf[:0]
+			w.discard = false
+		}
+	}
+	return
+}
+
+// Close logs any partial line left.
+func (w *lineWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.closed && len(w.buf) > 0 && !w.discard {
+		w.emit(w.buf, false)
+	}
+	w.buf = nil
+	w.closed = true
+	return nil
+}
+
+func (w *lineWriter) emit(line []byte, truncated bool) {
+	line = bytes.TrimSuffix(line, []byte{'\r'})
+	lvl := w.level
+	if len(line) >= 3 && line[0] == '<' && line[2] == '>' && line[1] >= '0' && line[1] <= '7' {
+		lvl = syslog.Priority(line[1] - '0')
+		line = line[3:]
+	}
+	msg := string(line)
+	kv := w.kv
+	if !w.raw {
+		var fields []interface{}
+		var ok bool
+		if len(line) > 0 && line[0] == '{' {
+			fields, ok = parseJSONLine(line)
+		} else {
+			fields, ok = parseLogfmtLine(line)
+		}
+		if ok {
+			msg = ""
+			kv = make([]interface{}, len(w.kv), len(w.kv)+len(fields))
+			copy(kv, w.kv)
+			for i := 0; i < len(fields); i += 2 {
+				k := fields[i].(string)
+				switch k {
+				case "msg", "message":
+					if s, ok := fields[i+1].(string); ok && msg == "" {
+						msg = s
+						continue
+					}
+				case "level", "lvl", "severity":
+					if l, ok := lineLevel(fields[i+1]); ok {
+						lvl = l
+						continue
+					}
+				}
+				kv = append(kv, k, fields[i+1])
+			}
+		}
+	}
+	if truncated {
+		kv = append(kv[:len(kv):len(kv)], "truncated", true)
+	}
+	if w.l.Does(lvl) {
+		w.l.log(lvl, msg, kv...)
+	}
+}
+
+func lineLevel(v interface{}) (syslog.Priority, bool) {
+	var s string
+	switch x := v.(type) {
+	case string:
+		s = x
+	case json.Number:
+		s = x.String()
+	default:
+		return 0, false
+	}
+	lvl, err := ParseLevel(s)
+	return lvl, err == nil
+}
+
+// parseJSONLine returns the fields of a JSON object in key order.
+func parseJSONLine(line []byte) (kv []interface{}, ok bool) {
+	var m map[string]interface{}
+	dec := json.NewDecoder(bytes.NewReader(line))
+	dec.UseNumber()
+	if err := dec.Decode(&m); err != nil || dec.More() {
+		return nil, false
+	}
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		kv = append(kv, k, m[k])
+	}
+	return kv, true
+}
+
+// parseLogfmtLine parses a line consisting only of key=value pairs.
+// Values can be quoted with Go string escapes.
+func parseLogfmtLine(line []byte) (kv []interface{}, ok bool) {
+	s := string(line)
+	for {
+		for len(s) > 0 && s[0] == ' ' {
+			s = s[1:]
+		}
+		if s == "" {
+			return kv, kv != nil
+		}
+		i := 0
+		for i < len(s) && s[i] != '=' && s[i] != ' ' && s[i] != '"' {
+			i++
+		}
+		if i == 0 || i == len(s) || s[i] != '=' {
+			return nil, false
+		}
+		key := s[:i]
+		s = s[i+1:]
+		var val string
+		if len(s) > 0 && s[0] == '"' {
+			q, err := strconv.QuotedPrefix(s)
+			if err != nil {
+				return nil, false
+			}
+			val, _ = strconv.Unquote(q)
+			s = s[len(q):]
+			if len(s) > 0 && s[0] != ' ' {
+				return nil, false
+			}
+		} else {
+			j := 0
+			for j < len(s) && s[j] != ' ' {
+				j++
+			}
+			val, s = s[:j], s[j:]
+		}
+		kv = append(kv, key, val)
+	}
+}
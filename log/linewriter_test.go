@@ -0,0 +1,72 @@
+package log
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/One-com/gonelog/syslog"
+	"testing"
+)
+
+func TestLineWriter(t *testing.T) {
+	rec := &eventRecorder{}
+	l := NewLogger(syslog.LOG_INFO, rec)
+	w := NewLineWriter(l, LineLevel(syslog.LOG_NOTICE), LineKV("stream", "stderr"), LineMaxLength(40))
+
+	fmt.Fprint(w, "plain ")
+	fmt.Fprint(w, "line\r\n<3>failed\n")
+	fmt.Fprint(w, `{"msg":"json","level":"warn","n":42}`+"\n")
+	fmt.Fprint(w, `lvl=error msg="hello world" user=bob`+"\n")
+	fmt.Fprint(w, "<7>not done\n")
+	fmt.Fprint(w, "a very long line indeed, longer than the cap\nshort\npartial")
+	w.Close()
+
+	expect := []struct {
+		lvl syslog.Priority
+		msg string
+		kv  string
+	}{
+		{syslog.LOG_NOTICE, "plain line", "[stream stderr]"},
+		{syslog.LOG_ERR, "failed", "[stream stderr]"},
+		{syslog.LOG_WARN, "json", "[stream stderr n 42]"},
+		{syslog.LOG_ERR, "hello world", "[stream stderr user bob]"},
+		{syslog.LOG_NOTICE, "a very long line indeed, longer than the", "[stream stderr truncated true]"},
+		{syslog.LOG_NOTICE, "short", "[stream stderr]"},
+		{syslog.LOG_NOTICE, "partial", "[stream stderr]"},
+	}
+	if len(rec.events) != len(expect) {
+		t.Fatalf("expected %d events, got %d", len(expect), len(rec.events))
+	}
+	for i, x := range expect {
+		e := rec.events[i]
+		if e.Lvl != x.lvl || e.Msg != x.msg || fmt.Sprint(rec.data[i]) != x.kv {
+			t.Errorf("event %d: got %d %q %v", i, e.Lvl, e.Msg, rec.data[i])
+		}
+	}
+	if n, ok := rec.data[2][3].(json.Number); !ok || n != "42" {
+		t.Errorf("JSON number not kept: %#v", rec.data[2][3])
+	}
+	if _, err := w.Write([]byte("more\n")); err == nil {
+		t.Error("write after close succeeded")
+	}
+}
+
+func TestParseLogfmtLine(t *testing.T) {
+	tests := []struct {
+		line string
+		kv   string
+		ok   bool
+	}{
+		{`a=1 b="x y" c=`, "[a 1 b x y c ]", true},
+		{`a="q\"uote"`, `[a q"uote]`, true},
+		{`just text`, "", false},
+		{`a=1 text`, "", false},
+		{`a="unterminated`, "", false},
+		{``, "", false},
+	}
+	for _, test := range tests {
+		kv, ok := parseLogfmtLine([]byte(test.line))
+		if ok != test.ok || (ok && fmt.Sprint(kv) != test.kv) {
+			t.Errorf("%q: got %v %v", test.line, kv, ok)
+		}
+	}
+}
@@ -0,0 +1,288 @@
+// Package logtest provides a recording gonelog Handler and helpers to make
+// assertions about what was logged in tests.
+//
+//	func TestSomething(t *testing.T) {
+//		rec := logtest.Capture(t, "mylib/db")
+//		mylib.DoSomething()
+//		logtest.AssertLogged(t, rec, syslog.LOG_ERROR, "connection lost", "retry", 3)
+//	}
+package logtest
+
+import (
+	"fmt"
+	"github.com/One-com/gonelog/log"
+	"github.com/One-com/gonelog/syslog"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Record is a copy of a log event retained by a Recorder.
+// Unlike log.Event it's safe to keep after the Handler returns.
+type Record struct {
+	Lvl  syslog.Priority
+	Msg  string
+	Name string
+	Data []interface{} // key/value data with any log.Lazy values evaluated
+	Time time.Time
+	File string
+	Line int
+	Func string
+}
+
+// Get returns the value of the first occurrence of key in the record data.
+// Values in groups are found by their dotted path, like "http.method".
+func (r Record) Get(key string) (v interface{}, ok bool) {
+	return lookup(r.Data, key)
+}
+
+func lookup(keyvals []interface{}, key string) (v interface{}, ok bool) {
+	for i := 0; i+1 < len(keyvals); i += 2 {
+		if g, isGroup := keyvals[i].(log.KVGroup); isGroup {
+			if strings.HasPrefix(key, g.Name+".") {
+				if v, ok = lookup(g.KV, key[len(g.Name)+1:]); ok {
+					return
+				}
+			}
+			continue
+		}
+		if keyString(keyvals[i]) == key {
+			return keyvals[i+1], true
+		}
+	}
+	return nil, false
+}
+
+func (r Record) String() string {
+	return fmt.Sprintf("<%d> (%s) %q %v", r.Lvl, r.Name, r.Msg, r.Data)
+}
+
+// Recorder is a Handler retaining copies of all events logged to it.
+type Recorder struct {
+	mu      sync.Mutex
+	records []Record
+}
+
+// NewRecorder creates an empty Recorder.
+func NewRecorder() *Recorder {
+	return &Recorder{}
+}
+
+// Log implements log.Handler
+func (r *Recorder) Log(e log.Event) error {
+	rec := Record{
+		Lvl:  e.Lvl,
+		Msg:  e.Msg,
+		Name: e.Name,
+		Time: e.Time(),
+		Func: e.FuncInfo(),
+	}
+	rec.File, rec.Line = e.FileInfo()
+	if data := e.ResolvedData(); data != nil {
+		rec.Data = make([]interface{}, len(data))
+		for i, v := range data {
+			rec.Data[i] = copyValue(v)
+		}
+	}
+	r.mu.Lock()
+	r.records = append(r.records, rec)
+	r.mu.Unlock()
+	return nil
+}
+
+// copyValue makes sure the value doesn't change after the event is recorded.
+// The data is already resolved, so there are no Lazy values left.
+func copyValue(v interface{}) interface{} {
+	switch x := v.(type) {
+	case log.KVGroup:
+		return log.KVGroup{Name: x.Name, KV: copyValue(x.KV).([]interface{})}
+	case log.KV:
+		kv := make(log.KV, len(x))
+		for k, v := range x {
+			kv[k] = copyValue(v)
+		}
+		return kv
+	case []interface{}:
+		s := make([]interface{}, len(x))
+		for i, v := range x {
+			s[i] = copyValue(v)
+		}
+		return s
+	}
+	return v
+}
+
+// Records returns a copy of all records so far.
+func (r *Recorder) Records() []Record {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	recs := make([]Record, len(r.records))
+	copy(recs, r.records)
+	return recs
+}
+
+// Len returns the number of records so far.
+func (r *Recorder) Len() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.records)
+}
+
+// Reset discards all records.
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	r.records = nil
+	r.mu.Unlock()
+}
+
+// Find returns all records satisfying all the matchers.
+func (r *Recorder) Find(matchers ...Matcher) (found []Record) {
+	for _, rec := range r.Records() {
+		if matchAll(rec, matchers) {
+			found = append(found, rec)
+		}
+	}
+	return
+}
+
+// Has returns whether any record satisfies all the matchers.
+func (r *Recorder) Has(matchers ...Matcher) bool {
+	return len(r.Find(matchers...)) > 0
+}
+
+func matchAll(rec Record, matchers []Matcher) bool {
+	for _, m := range matchers {
+		if !m(rec) {
+			return false
+		}
+	}
+	return true
+}
+
+//---
+
+// Matcher decides whether a Record is of interest.
+type Matcher func(r Record) bool
+
+// Level matches records with exactly the given level.
+func Level(lvl syslog.Priority) Matcher {
+	return func(r Record) bool { return r.Lvl == lvl }
+}
+
+// MaxLevel matches records with the given level or more severe (numerically lower).
+func MaxLevel(lvl syslog.Priority) Matcher {
+	return func(r Record) bool { return r.Lvl <= lvl }
+}
+
+// Name matches records logged by a Logger with the given name.
+func Name(name string) Matcher {
+	return func(r Record) bool { return r.Name == name }
+}
+
+// Msg matches records with the given message. Any trailing newline (from Println)
+// is ignored.
+func Msg(msg string) Matcher {
+	return func(r Record) bool { return strings.TrimSuffix(r.Msg, "\n") == msg }
+}
+
+// MsgMatch matches records having a message matching the regular expression.
+// It panics if the expression doesn't compile.
+func MsgMatch(expr string) Matcher {
+	re := regexp.MustCompile(expr)
+	return func(r Record) bool { return re.MatchString(r.Msg) }
+}
+
+// HasKey matches records having the key in its data.
+func HasKey(key string) Matcher {
+	return func(r Record) bool {
+		_, ok := r.Get(key)
+		return ok
+	}
+}
+
+// KV matches records having all the key/value pairs in its data.
+// Values are compared with reflect.DeepEqual.
+func KV(kv ...interface{}) Matcher {
+	return func(r Record) bool {
+		for i := 0; i+1 < len(kv); i += 2 {
+			v, ok := r.Get(keyString(kv[i]))
+			if !ok || !reflect.DeepEqual(v, kv[i+1]) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+func keyString(k interface{}) string {
+	if s, ok := k.(string); ok {
+		return s
+	}
+	return fmt.Sprint(k)
+}
+
+//---
+
+// AssertLogged fails the test if no record has the given level, message
+// and key/value data. It returns the first matching record.
+func AssertLogged(t testing.TB, r *Recorder, lvl syslog.Priority, msg string, kv ...interface{}) (rec Record) {
+	t.Helper()
+	found := r.Find(Level(lvl), Msg(msg), KV(kv...))
+	if len(found) == 0 {
+		t.Errorf("no log event <%d> %q %v. Logged:\n%s", lvl, msg, kv, dump(r))
+		return
+	}
+	return found[0]
+}
+
+// AssertNotLogged fails the test if any record has the given level, message
+// and key/value data.
+func AssertNotLogged(t testing.TB, r *Recorder, lvl syslog.Priority, msg string, kv ...interface{}) {
+	t.Helper()
+	if r.Has(Level(lvl), Msg(msg), KV(kv...)) {
+		t.Errorf("unexpected log event <%d> %q %v. Logged:\n%s", lvl, msg, kv, dump(r))
+	}
+}
+
+// AssertCount fails the test if not exactly n records satisfy the matchers.
+func AssertCount(t testing.TB, r *Recorder, n int, matchers ...Matcher) {
+	t.Helper()
+	if found := r.Find(matchers...); len(found) != n {
+		t.Errorf("expected %d matching log events, got %d. Logged:\n%s", n, len(found), dump(r))
+	}
+}
+
+func dump(r *Recorder) string {
+	var b strings.Builder
+	for _, rec := range r.Records() {
+		b.WriteString("\t")
+		b.WriteString(rec.String())
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+//---
+
+// Capture routes events from the named Logger (and the subtree below it not
+// having its own Handler) to a new Recorder for the duration of the test.
+// The original Handler is restored when the test finishes.
+// The empty name captures the default Logger.
+func Capture(t testing.TB, name string) *Recorder {
+	var l *log.Logger
+	if name == "" {
+		l = log.Default()
+	} else {
+		l = log.GetLogger(name)
+	}
+	rec := NewRecorder()
+	old := l.Handler()
+	l.SetHandler(rec)
+	t.Cleanup(func() {
+		l.SetHandler(old)
+	})
+	return rec
+}
@@ -0,0 +1,85 @@
+package logtest
+
+import (
+	"github.com/One-com/gonelog/log"
+	"github.com/One-com/gonelog/syslog"
+	"testing"
+)
+
+func TestCapture(t *testing.T) {
+	l := log.GetLogger("logtest/sub")
+	l.SetLevel(syslog.LOG_DEBUG)
+
+	var rec *Recorder
+	t.Run("capture", func(t *testing.T) {
+		rec = Capture(t, "logtest")
+		n := 1
+		l.With("conn", 7).ERROR("failed", "retry", 3, "lazy", log.Lazy(func() interface{} { return n }))
+		n = 2
+		l.DEBUG("details")
+		l.Println("printed")
+
+		AssertLogged(t, rec, syslog.LOG_ERROR, "failed", "retry", 3, "conn", 7, "lazy", 1)
+		AssertLogged(t, rec, syslog.LOG_INFO, "printed")
+		AssertNotLogged(t, rec, syslog.LOG_ERROR, "details")
+		AssertCount(t, rec, 2, MaxLevel(syslog.LOG_INFO), Name("logtest/sub"))
+		AssertCount(t, rec, 1, MsgMatch("^det"), Level(syslog.LOG_DEBUG))
+		AssertCount(t, rec, 1, HasKey("conn"))
+	})
+
+	if h := log.GetLogger("logtest").Handler(); h != nil {
+		t.Errorf("handler not restored: %v", h)
+	}
+	l.ERROR("after")
+	if rec.Has(Msg("after")) {
+		t.Error("recorded event after test finished")
+	}
+}
+
+func TestAssertFailure(t *testing.T) {
+	rec := NewRecorder()
+	l := log.NewLogger(syslog.LOG_DEBUG, rec)
+	l.WARN("warning", "k", "v")
+
+	inner := &fakeTB{TB: t}
+	AssertLogged(inner, rec, syslog.LOG_WARN, "warning", "k", "other")
+	if !inner.failed {
+		t.Error("AssertLogged didn't fail on wrong value")
+	}
+	inner.failed = false
+	AssertLogged(inner, rec, syslog.LOG_WARN, "warning", "k", "v")
+	if inner.failed {
+		t.Error("AssertLogged failed on matching event")
+	}
+	rec.Reset()
+	if rec.Len() != 0 {
+		t.Error("Reset didn't discard records")
+	}
+}
+
+// fakeTB records failures instead of failing the test
+type fakeTB struct {
+	testing.TB
+	failed bool
+}
+
+func (f *fakeTB) Helper() {}
+func (f *fakeTB) Errorf(format string, args ...interface{}) {
+	f.failed = true
+}
+
+func TestRecordGroups(t *testing.T) {
+	rec := NewRecorder()
+	l := log.NewLogger(syslog.LOG_DEBUG, rec)
+	path := []interface{}{"/"}
+	l.WithGroup("http").INFO("request", "method", "GET", log.Group("req", "path", path, "n", log.Lazy(func() interface{} { return 3 })))
+	path[0] = "/changed"
+
+	AssertLogged(t, rec, syslog.LOG_INFO, "request", "http.method", "GET", "http.req.n", 3)
+	if v, ok := rec.Records()[0].Get("http.req.path"); !ok || v.([]interface{})[0] != "/" {
+		t.Errorf("grouped value not copied: %v", v)
+	}
+	if _, ok := rec.Records()[0].Get("method"); ok {
+		t.Error("found grouped key without its group")
+	}
+}
@@ -33,6 +33,9 @@ type manager struct {
 	mu       sync.Mutex
 	root     *Logger
 	registry map[string]interface{} // contains either *Logger or *placeholder
+
+	// Pending level config for Loggers matching, also those not yet created.
+	specs []levelSpec
 }
 
 var man *manager
@@ -58,6 +61,7 @@ func (m *manager) getLogger(name string) (l *Logger) {
 			m.registry[name] = l
 			m.fixupChildren(p, l)
 			m.fixupParents(l)
+			m.applySpecs(l)
 			return
 		}
 		l = node.(*Logger) // must be a Logger.
@@ -65,10 +69,51 @@ func (m *manager) getLogger(name string) (l *Logger) {
 		l = newLogger(name)
 		m.registry[name] = l
 		m.fixupParents(l)
+		m.applySpecs(l)
 	}
 	return
 }
 
+// applySpecs applies any level config matching a new named Logger.
+// must be called under manager mutex lock
+func (m *manager) applySpecs(l *Logger) {
+	for i := range m.specs {
+		if m.specs[i].matches(l) {
+			m.specs[i].apply(l)
+		}
+	}
+}
+
+// setSpecs replaces the level config and applies it to all existing Loggers.
+func (m *manager) setSpecs(specs []levelSpec) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.specs = specs
+	for i := range specs {
+		if specs[i].pattern == nil {
+			specs[i].apply(m.root)
+		}
+	}
+	for _, node := range m.registry {
+		if l, ok := node.(*Logger); ok {
+			m.applySpecs(l)
+		}
+	}
+}
+
+// loggers returns the root and all named Loggers
+func (m *manager) loggers() []*Logger {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	ls := []*Logger{m.root}
+	for _, node := range m.registry {
+		if l, ok := node.(*Logger); ok {
+			ls = append(ls, l)
+		}
+	}
+	return ls
+}
+
 // Ensure that there are either loggers or placeholders all the way
 // from the specified logger to the root of the logger hierarchy.
 func (m *manager) fixupParents(l *Logger) {
@@ -0,0 +1,143 @@
+package log
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// FieldEncoder receives the fields of a LogMarshaler.
+type FieldEncoder interface {
+	// Field adds a key/value pair. The value can itself be a LogMarshaler.
+	Field(key string, value interface{})
+}
+
+// LogMarshaler is implemented by types which log themselves as structured fields.
+// Logged as the value of key "user", the fields are written as "user.id=..." by the
+// std and logfmt formatters and as a nested object by the JSON formatter.
+//
+//	func (u *User) MarshalLog(enc log.FieldEncoder) error {
+//		enc.Field("id", u.ID)
+//		enc.Field("name", u.Name)
+//		return nil
+//	}
+//
+// MarshalLog is called at most once per event, when the event is formatted.
+//
+// Errors are logged with their message. The fields of an error having a Fields() method
+// and the messages of the errors it wraps are logged next to it:
+// err="read config: EOF" err.causes=EOF
+type LogMarshaler interface {
+	MarshalLog(enc FieldEncoder) error
+}
+
+// Errors exposing fields like this get them logged next to the message.
+type fieldsMapper interface {
+	Fields() map[string]interface{}
+}
+type fieldsLister interface {
+	Fields() []interface{}
+}
+
+// Nested LogMarshalers beyond this depth are not expanded, to stop any cycles.
+const maxMarshalDepth = 8
+
+type fieldEncoder struct {
+	kv []interface{}
+}
+
+func (f *fieldEncoder) Field(key string, value interface{}) {
+	f.kv = append(f.kv, key, value)
+}
+
+// errorCauses are the messages of the errors in an errors.Unwrap() chain.
+// JSON gets an array.
+type errorCauses []string
+
+func (c errorCauses) String() string {
+	return strings.Join(c, "; ")
+}
+
+// expandable tells whether a value is to be expanded by marshalFields.
+func expandable(v interface{}) bool {
+	switch x := v.(type) {
+	case error:
+		switch x.(type) {
+		case LogMarshaler, fieldsMapper, fieldsLister:
+			return true
+		}
+		return errors.Unwrap(x) != nil
+	case LogMarshaler:
+		return true
+	}
+	return false
+}
+
+// expand appends the expandable value v of key k to out.
+// A LogMarshaler becomes a KVGroup. An error keeps its message under the key, with its
+// fields and the messages of any wrapped errors ("causes") as "key.field" siblings.
+func expand(out []interface{}, k, v interface{}, depth int) []interface{} {
+	name := keyString(k)
+	err, ok := v.(error)
+	if !ok {
+		return append(out, KVGroup{Name: name, KV: marshalFields(v, depth)}, nil)
+	}
+	out = append(out, k, safeError(err))
+	kv := marshalFields(v, depth)
+	for i := 0; i+1 < len(kv); i += 2 {
+		if g, ok := kv[i].(KVGroup); ok {
+			out = append(out, KVGroup{Name: name + "." + g.Name, KV: g.KV}, nil)
+		} else {
+			out = append(out, name+"."+keyString(kv[i]), kv[i+1])
+		}
+	}
+	return out
+}
+
+// marshalFields returns the fields of an expandable value.
+// Errors get any wrapped errors as "causes".
+func marshalFields(v interface{}, depth int) (kv []interface{}) {
+	switch x := v.(type) {
+	case LogMarshaler:
+		kv = safeMarshalLog(x, kv)
+	case fieldsMapper:
+		fields := x.Fields()
+		keys := make([]string, 0, len(fields))
+		for k := range fields {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			kv = append(kv, k, fields[k])
+		}
+	case fieldsLister:
+		kv = append(kv, normalize(x.Fields())...)
+	}
+	if err, ok := v.(error); ok {
+		var causes errorCauses
+		for c := errors.Unwrap(err); c != nil; c = errors.Unwrap(c) {
+			causes = append(causes, c.Error())
+		}
+		if causes != nil {
+			kv = append(kv, "causes", causes)
+		}
+	}
+	if depth < maxMarshalDepth && needsResolve(kv) {
+		kv = resolveData(kv, depth+1)
+	}
+	return
+}
+
+func safeMarshalLog(m LogMarshaler, kv []interface{}) (out []interface{}) {
+	enc := &fieldEncoder{kv: kv}
+	defer func() {
+		if p := recover(); p != nil {
+			out = append(enc.kv, errorKey, fmt.Errorf("PANIC in MarshalLog: %v", p))
+		}
+	}()
+	if err := m.MarshalLog(enc); err != nil {
+		enc.kv = append(enc.kv, errorKey, err)
+	}
+	return enc.kv
+}
@@ -0,0 +1,89 @@
+package log
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/One-com/gonelog/syslog"
+	"strings"
+	"testing"
+)
+
+type testUser struct {
+	id   int
+	name string
+	org  *testOrg
+}
+
+func (u *testUser) MarshalLog(enc FieldEncoder) error {
+	enc.Field("id", u.id)
+	enc.Field("name", u.name)
+	if u.org != nil {
+		enc.Field("org", u.org)
+	}
+	return nil
+}
+
+type testOrg struct{ name string }
+
+func (o *testOrg) MarshalLog(enc FieldEncoder) error {
+	enc.Field("name", o.name)
+	return nil
+}
+
+type fieldsError struct{ code int }
+
+func (e fieldsError) Error() string                  { return "request failed" }
+func (e fieldsError) Fields() map[string]interface{} { return map[string]interface{}{"code": e.code} }
+
+func TestLogMarshaler(t *testing.T) {
+	var std, js bytes.Buffer
+	l := NewLogger(syslog.LOG_INFO, MultiHandler(
+		NewStdFormatter(&std, "", Llevel),
+		NewJSONFormatter(&js)))
+
+	u := &testUser{id: 7, name: "bob", org: &testOrg{name: "acme"}}
+	err := fmt.Errorf("save: %w", fmt.Errorf("db: %w", errors.New("timeout")))
+	l.ERROR("failed", "user", u, "err", err, "ferr", fieldsError{code: 500}, "plain", errors.New("plain"))
+
+	want := `<3>failed user.id=7 user.name=bob user.org.name=acme ` +
+		`err="save: db: timeout" err.causes="db: timeout; timeout" ` +
+		`ferr="request failed" ferr.code=500 plain=plain` + "\n"
+	if std.String() != want {
+		t.Errorf("got  %q\nwant %q", std.String(), want)
+	}
+	for _, s := range []string{
+		`"user":{"id":7,"name":"bob","org":{"name":"acme"}}`,
+		`"err":"save: db: timeout"`,
+		`"err.causes":["db: timeout","timeout"]`,
+		`"ferr":"request failed"`,
+		`"ferr.code":500`,
+		`"plain":"plain"`,
+	} {
+		if !strings.Contains(js.String(), s) {
+			t.Errorf("JSON %s doesn't contain %s", js.String(), s)
+		}
+	}
+}
+
+type fieldsValue string
+
+func (v fieldsValue) Fields() []interface{} { return []interface{}{"n", 1} }
+
+func TestExpandOnlyErrorFields(t *testing.T) {
+	var std bytes.Buffer
+	l := NewLogger(syslog.LOG_INFO, NewStdFormatter(&std, "", 0))
+	l.INFO("x", "v", fieldsValue("abc"), "err", fmt.Errorf("read config: %w", errors.New("EOF")))
+
+	if want := `x v=abc err="read config: EOF" err.causes=EOF` + "\n"; std.String() != want {
+		t.Errorf("got %q, want %q", std.String(), want)
+	}
+
+	std.Reset()
+	f := MustCompileFilter(`kv.err.causes == "EOF"`)
+	l = NewLogger(syslog.LOG_INFO, FilterHandler(f.Match, NewStdFormatter(&std, "", 0)))
+	l.INFO("x", "err", fmt.Errorf("read config: %w", errors.New("EOF")))
+	if std.Len() == 0 {
+		t.Error("filter didn't find the causes of the error")
+	}
+}
@@ -0,0 +1,170 @@
+package log
+
+import (
+	"fmt"
+	"github.com/One-com/gonelog/syslog"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"unsafe"
+)
+
+// PanicAction decides what Recover() does after logging a panic.
+type PanicAction int
+
+const (
+	PanicSwallow PanicAction = iota // Stop the panic. The goroutine continues after the deferred Recover().
+	PanicRepanic                    // Continue panicking with the same value.
+	PanicExit                       // Shut down logging and exit the process like Fatal().
+)
+
+type recoverConfig struct {
+	action PanicAction
+	lvl    syslog.Priority
+	msg    string
+}
+
+// RecoverOption configures Recover() and Go()
+type RecoverOption func(*recoverConfig)
+
+// OnPanic sets what to do after a panic is logged. Default is PanicSwallow.
+func OnPanic(a PanicAction) RecoverOption {
+	return func(c *recoverConfig) {
+		c.action = a
+	}
+}
+
+// RecoverLevel sets the level panics are logged at. Default is CRIT.
+func RecoverLevel(lvl syslog.Priority) RecoverOption {
+	return func(c *recoverConfig) {
+		c.lvl = lvl
+	}
+}
+
+// RecoverMessage sets the message panics are logged with. Default is "panic".
+func RecoverMessage(msg string) RecoverOption {
+	return func(c *recoverConfig) {
+		c.msg = msg
+	}
+}
+
+// loggedPanics are the latest panic strings of Panic*(), telling Recover() they have
+// already been logged. They are told apart from other panics with the same text by
+// the string data pointer: Panic*() strings are made by fmt.
+var loggedPanics struct {
+	mu   sync.Mutex
+	ring [16]string
+	next int
+}
+
+// panicLogged marks the panic string s as logged, returning it.
+func panicLogged(s string) string {
+	if s == "" {
+		return s
+	}
+	loggedPanics.mu.Lock()
+	loggedPanics.ring[loggedPanics.next] = s
+	loggedPanics.next = (loggedPanics.next + 1) % len(loggedPanics.ring)
+	loggedPanics.mu.Unlock()
+	return s
+}
+
+// wasLogged tells whether the panic value is from Panic*(), forgetting it.
+func wasLogged(v interface{}) bool {
+	s, ok := v.(string)
+	if !ok || s == "" {
+		return false
+	}
+	loggedPanics.mu.Lock()
+	defer loggedPanics.mu.Unlock()
+	for i, r := range loggedPanics.ring {
+		if len(r) == len(s) && stringData(r) == stringData(s) {
+			loggedPanics.ring[i] = ""
+			return true
+		}
+	}
+	return false
+}
+
+func stringData(s string) unsafe.Pointer {
+	return *(*unsafe.Pointer)(unsafe.Pointer(&s))
+}
+
+// panicFrame returns the stack frame which panicked, when called (indirectly)
+// from a function deferred during the panic: The first frame after the runtime
+// panic functions.
+func panicFrame() (frame runtime.Frame, ok bool) {
+	var pcs [32]uintptr
+	n := runtime.Callers(2, pcs[:])
+	frames := runtime.CallersFrames(pcs[:n])
+	panicking := false
+	for {
+		f, more := frames.Next()
+		if f.Function == "runtime.gopanic" {
+			panicking = true
+		} else if panicking && !strings.HasPrefix(f.Function, "runtime.") {
+			return f, true
+		}
+		if !more {
+			return frame, false
+		}
+	}
+}
+
+// Recover recovers a panic and logs it to the Logger with the panic value and
+// the stack of the panicking goroutine. It must be deferred directly:
+//
+//	defer log.Recover(l)
+//
+// The event has the Logger context key/value data (from With()) and keys
+// "panic" and "stack". Any code info is that of the panicking function.
+// Panics raised by Panic*() are not logged again, since they have already been logged.
+// After logging, the panic is swallowed, re-raised or the process exits as set by OnPanic().
+func Recover(l *Logger, opts ...RecoverOption) {
+	v := recover()
+	if v == nil {
+		return
+	}
+	c := recoverConfig{lvl: syslog.LOG_CRIT, msg: "panic"}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	if !wasLogged(v) && l.Does(c.lvl) {
+		e := l.newEvent(c.lvl, c.msg, normalize([]interface{}{"panic", panicValue(v), "stack", string(debug.Stack())}))
+		// Report where the panic happened, not the runtime code calling Recover()
+		if f, ok := panicFrame(); ok && e.fok {
+			e.file, e.line, e.fn = f.File, f.Line, f.Function
+		}
+		l.h.Log(e)
+	}
+	switch c.action {
+	case PanicRepanic:
+		panic(v)
+	case PanicExit:
+		exit()
+	}
+}
+
+// Go runs fn in a new goroutine, logging any panic with Recover()
+//
+//	log.Go(l, func() { serve(conn) }, log.OnPanic(log.PanicExit))
+func Go(l *Logger, fn func(), opts ...RecoverOption) {
+	go func() {
+		defer Recover(l, opts...)
+		fn()
+	}()
+}
+
+// panicValue makes sure the panic value is logged as something readable.
+func panicValue(v interface{}) interface{} {
+	switch x := v.(type) {
+	case string:
+		return x
+	case error:
+		return x
+	case fmt.Stringer:
+		return x
+	}
+	return fmt.Sprintf("%v", v)
+}
@@ -0,0 +1,159 @@
+package log
+
+import (
+	"errors"
+	"github.com/One-com/gonelog/syslog"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type eventRecorder struct {
+	mu     sync.Mutex
+	events []Event
+	data   [][]interface{}
+}
+
+func (r *eventRecorder) Log(e Event) error {
+	r.mu.Lock()
+	// the event is reused after Log returns. Keep a copy
+	ne := *e.event
+	r.events = append(r.events, Event{&ne})
+	r.data = append(r.data, append([]interface{}(nil), e.Data...))
+	r.mu.Unlock()
+	return nil
+}
+
+func TestRecover(t *testing.T) {
+	rec := &eventRecorder{}
+	l := NewLogger(syslog.LOG_INFO, rec).With("req", 42)
+
+	func() {
+		defer Recover(l)
+		panic(errors.New("boom"))
+	}()
+
+	if len(rec.events) != 1 {
+		t.Fatalf("expected 1 event, got %d", len(rec.events))
+	}
+	e := rec.events[0]
+	if e.Lvl != syslog.LOG_CRIT || e.Msg != "panic" {
+		t.Errorf("unexpected event: %d %q", e.Lvl, e.Msg)
+	}
+	kv := rec.data[0]
+	if len(kv) != 6 || kv[0] != "req" || kv[2] != "panic" || kv[4] != "stack" {
+		t.Fatalf("unexpected data: %v", kv)
+	}
+	if err, ok := kv[3].(error); !ok || err.Error() != "boom" {
+		t.Errorf("unexpected panic value: %v", kv[3])
+	}
+	if !strings.Contains(kv[5].(string), "TestRecover") {
+		t.Errorf("stack doesn't contain test function: %s", kv[5])
+	}
+}
+
+func TestRecoverRepanic(t *testing.T) {
+	rec := &eventRecorder{}
+	l := NewLogger(syslog.LOG_INFO, rec)
+
+	var v interface{}
+	func() {
+		defer func() { v = recover() }()
+		defer Recover(l, OnPanic(PanicRepanic), RecoverLevel(syslog.LOG_ERR))
+		panic(17)
+	}()
+	if v != 17 {
+		t.Errorf("panic not re-raised: %v", v)
+	}
+	if len(rec.events) != 1 || rec.events[0].Lvl != syslog.LOG_ERR || rec.data[0][1] != "17" {
+		t.Errorf("panic not logged correctly")
+	}
+}
+
+func TestRecoverPanicLoggedOnce(t *testing.T) {
+	rec := &eventRecorder{}
+	l := NewLogger(syslog.LOG_INFO, rec)
+
+	func() {
+		defer Recover(l)
+		l.Panicf("bad %s", "thing")
+	}()
+	func() {
+		defer func() {
+			if v, ok := recover().(string); !ok || v != "plain" {
+				t.Errorf("Panic() value not a plain string: %#v", v)
+			}
+		}()
+		l.Panic("plain")
+	}()
+	if len(rec.events) != 2 || rec.events[0].Msg != "bad thing" {
+		t.Errorf("expected only the Panicf event, got %d events", len(rec.events))
+	}
+
+	// A Panic*() recovered elsewhere doesn't hide later panics with the same text
+	func() {
+		defer func() { recover() }()
+		l.Panic("same")
+	}()
+	func() {
+		defer Recover(l)
+		panic("same")
+	}()
+	if len(rec.events) != 4 || rec.events[3].Msg != "panic" {
+		t.Errorf("unrelated panic not logged")
+	}
+}
+
+func TestRecoverCaller(t *testing.T) {
+	rec := &eventRecorder{}
+	l := NewLogger(syslog.LOG_INFO, rec)
+	l.DoCodeInfo(true)
+
+	var line int
+	func() {
+		defer Recover(l)
+		_, _, line, _ = runtime.Caller(0)
+		panic("here")
+	}()
+	var s []int
+	func() {
+		defer Recover(l)
+		_ = s[3] // runtime error
+	}()
+	if len(rec.events) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(rec.events))
+	}
+	if file, l := rec.events[0].FileInfo(); !strings.HasSuffix(file, "recover_test.go") || l != line+1 {
+		t.Errorf("wrong caller %s:%d, want line %d", file, l, line+1)
+	}
+	if file, l := rec.events[1].FileInfo(); !strings.HasSuffix(file, "recover_test.go") || l != line+6 {
+		t.Errorf("wrong caller %s:%d, want line %d", file, l, line+6)
+	}
+}
+
+func TestGo(t *testing.T) {
+	rec := &eventRecorder{}
+	l := NewLogger(syslog.LOG_INFO, rec)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	Go(l, func() {
+		defer wg.Done()
+		panic("in goroutine")
+	})
+	wg.Wait()
+
+	// wg.Done() runs before Recover(). Wait for it to log
+	for i := 0; i < 1000; i++ {
+		rec.mu.Lock()
+		n := len(rec.events)
+		rec.mu.Unlock()
+		if n == 1 {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Error("panic in goroutine not logged")
+}
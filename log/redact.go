@@ -0,0 +1,278 @@
+package log
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// RedactFunc replaces a secret string with something safe to log.
+type RedactFunc func(secret string) string
+
+// RedactMask replaces the secret entirely with mask.
+func RedactMask(mask string) RedactFunc {
+	return func(string) string {
+		return mask
+	}
+}
+
+// RedactPartial masks all but the last keep characters of the secret with '*'.
+// Secrets shorter than twice keep are masked entirely, to not reveal most of them.
+func RedactPartial(keep int) RedactFunc {
+	return func(s string) string {
+		n := utf8.RuneCountInString(s)
+		if n < 2*keep {
+			return strings.Repeat("*", n)
+		}
+		i := len(s)
+		for k := 0; k < keep; k++ {
+			_, size := utf8.DecodeLastRuneInString(s[:i])
+			i -= size
+		}
+		return strings.Repeat("*", n-keep) + s[i:]
+	}
+}
+
+// RedactHash replaces the secret with a keyed hash (HMAC-SHA256) of it, so the
+// same secret can be correlated across log events without being revealed.
+func RedactHash(key []byte) RedactFunc {
+	return func(s string) string {
+		mac := hmac.New(sha256.New, key)
+		mac.Write([]byte(s))
+		return "hmac:" + hex.EncodeToString(mac.Sum(nil)[:8])
+	}
+}
+
+// Some regular expressions for secrets in messages and string values.
+var (
+	RedactBearerToken = regexp.MustCompile(`(?i)\bbearer\s+[a-z0-9\-._~+/]+=*`)
+	RedactCreditCard  = regexp.MustCompile(`\b(?:\d[ -]?){12,18}\d\b`)
+	RedactEmail       = regexp.MustCompile(`[a-zA-Z0-9._%+\-]+@[a-zA-Z0-9.\-]+\.[a-zA-Z]{2,}`)
+)
+
+// DefaultRedactKeys are the key patterns used if no RedactKeys option is given.
+var DefaultRedactKeys = []string{"*password*", "*passwd*", "*secret*", "*token*", "*apikey*", "*api_key*", "authorization", "cookie"}
+
+type redactHandler struct {
+	h       Handler
+	keys    []string         // lower case glob patterns
+	res     []*regexp.Regexp // patterns to scan strings for
+	replace RedactFunc
+}
+
+// RedactOption configures a RedactHandler
+type RedactOption func(*redactHandler)
+
+// RedactKeys sets the patterns for keys which values are always redacted.
+// Patterns are case-insensitive globs, where '*' matches any sequence of
+// characters and '?' a single character.
+func RedactKeys(patterns ...string) RedactOption {
+	return func(r *redactHandler) {
+		r.keys = make([]string, len(patterns))
+		for i, p := range patterns {
+			r.keys[i] = strings.ToLower(p)
+		}
+	}
+}
+
+// RedactValues adds regular expressions for secrets to be redacted from messages
+// and string values regardless of key.
+func RedactValues(res ...*regexp.Regexp) RedactOption {
+	return func(r *redactHandler) {
+		r.res = append(r.res, res...)
+	}
+}
+
+// RedactWith sets the replacement strategy. Default is RedactMask("[REDACTED]")
+func RedactWith(fn RedactFunc) RedactOption {
+	return func(r *redactHandler) {
+		r.replace = fn
+	}
+}
+
+// RedactHandler masks secrets in events before passing them on to the next Handler.
+// Values of keys matching the key patterns are replaced entirely. Messages and
+// string values (also inside KV maps and Lazy results, which are evaluated) are scanned for the
+// regular expressions given with RedactValues(). Other values are scanned as formatted by
+// fmt.Sprint() (or by String()/Error()) and replaced by the redacted string if anything matched.
+// The original event is never modified. A redacted copy is passed on.
+func RedactHandler(h Handler, options ...RedactOption) Handler {
+	r := &redactHandler{
+		h:       h,
+		replace: RedactMask("[REDACTED]"),
+	}
+	RedactKeys(DefaultRedactKeys...)(r)
+	for _, option := range options {
+		option(r)
+	}
+	return r
+}
+
+func (r *redactHandler) Chained() []interface{} {
+	return []interface{}{r.h}
+}
+
+func (r *redactHandler) Log(e Event) error {
+	msg := r.redactString(e.Msg)
+	data := r.redactKeyvals(e.ResolvedData())
+	if msg == e.Msg && data == nil {
+		return r.h.Log(e)
+	}
+	e.Time() // the copy must have the same timestamp
+	ne := *e.event
+	ne.Msg = msg
+	if data != nil {
+		ne.Data = data
+		ne.resolved = data
+	}
+	return r.h.Log(Event{&ne})
+}
+
+// redactKeyvals returns a redacted copy of keyvals, or nil if nothing needed redaction.
+func (r *redactHandler) redactKeyvals(keyvals []interface{}) (out []interface{}) {
+	for i := 0; i+1 < len(keyvals); i += 2 {
+		k, v := keyvals[i], keyvals[i+1]
+		changed := false
+		if g, ok := k.(KVGroup); ok {
+			if kv := r.redactKeyvals(g.KV); kv != nil {
+				k, changed = KVGroup{Name: g.Name, KV: kv}, true
+			}
+		} else {
+			v, changed = r.redactKV(k, v)
+		}
+		if changed {
+			if out == nil {
+				out = make([]interface{}, len(keyvals))
+				copy(out, keyvals)
+			}
+			out[i], out[i+1] = k, v
+		}
+	}
+	return
+}
+
+func (r *redactHandler) redactKV(k, v interface{}) (interface{}, bool) {
+	var key string
+	switch x := k.(type) {
+	case string:
+		key = x
+	case fmt.Stringer:
+		key = safeString(x)
+	default:
+		key = fmt.Sprint(x)
+	}
+	if r.secretKey(key) {
+		return r.replace(fmt.Sprint(v)), true
+	}
+	return r.redactValue(v)
+}
+
+func (r *redactHandler) redactValue(v interface{}) (interface{}, bool) {
+	switch x := v.(type) {
+	case string:
+		s := r.redactString(x)
+		return s, s != x
+	case KV:
+		var out KV
+		for k, kv := range x {
+			nv, changed := r.redactKV(k, kv)
+			if changed {
+				if out == nil {
+					out = make(KV, len(x))
+					for k, kv := range x {
+						out[k] = kv
+					}
+				}
+				out[k] = nv
+			}
+		}
+		if out != nil {
+			return out, true
+		}
+	case error:
+		if len(r.res) > 0 {
+			if s := safeError(x); s != nil {
+				rs := r.redactString(s.(string))
+				if rs != s.(string) {
+					return rs, true
+				}
+			}
+		}
+	case fmt.Stringer:
+		if len(r.res) > 0 {
+			s := safeString(x)
+			if rs := r.redactString(s); rs != s {
+				return rs, true
+			}
+		}
+	case nil:
+	default:
+		if len(r.res) > 0 {
+			s := fmt.Sprint(x)
+			if rs := r.redactString(s); rs != s {
+				return rs, true
+			}
+		}
+	}
+	return v, false
+}
+
+func (r *redactHandler) redactString(s string) string {
+	for _, re := range r.res {
+		s = re.ReplaceAllStringFunc(s, r.replace)
+	}
+	return s
+}
+
+func (r *redactHandler) secretKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, p := range r.keys {
+		if globMatch(p, key) {
+			return true
+		}
+	}
+	return false
+}
+
+// globMatch matches s against a pattern where '*' matches any sequence of
+// bytes and '?' any single character.
+func globMatch(pattern, s string) bool {
+	var px, sx int
+	var nextPx, nextSx = -1, -1
+	for px < len(pattern) || sx < len(s) {
+		if px < len(pattern) {
+			switch c := pattern[px]; c {
+			case '*':
+				// try to match the rest at sx, remember to try sx+1 on mismatch
+				nextPx = px
+				nextSx = sx + 1
+				px++
+				continue
+			case '?':
+				if sx < len(s) {
+					_, size := utf8.DecodeRuneInString(s[sx:])
+					px++
+					sx += size
+					continue
+				}
+			default:
+				if sx < len(s) && s[sx] == c {
+					px++
+					sx++
+					continue
+				}
+			}
+		}
+		if nextSx > 0 && nextSx <= len(s) {
+			px = nextPx
+			sx = nextSx
+			continue
+		}
+		return false
+	}
+	return true
+}
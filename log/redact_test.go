@@ -0,0 +1,103 @@
+package log
+
+import (
+	"bytes"
+	"errors"
+	"github.com/One-com/gonelog/syslog"
+	"testing"
+	"time"
+)
+
+func TestGlobMatch(t *testing.T) {
+	tests := []struct {
+		pattern, s string
+		match      bool
+	}{
+		{"*password*", "password", true},
+		{"*password*", "db_password_old", true},
+		{"*password*", "passwd", false},
+		{"authorization", "authorization", true},
+		{"authorization", "authorizations", false},
+		{"a?c", "abc", true},
+		{"a?c", "ac", false},
+		{"*.key", "x.y.key", true},
+		{"*", "", true},
+		{"", "x", false},
+	}
+	for _, test := range tests {
+		if m := globMatch(test.pattern, test.s); m != test.match {
+			t.Errorf("globMatch(%q, %q) = %v", test.pattern, test.s, m)
+		}
+	}
+}
+
+func TestRedactPartialHash(t *testing.T) {
+	if s := RedactPartial(4)("4111111111111111"); s != "************1111" {
+		t.Errorf("partial: %q", s)
+	}
+	if s := RedactPartial(4)("short"); s != "*****" {
+		t.Errorf("partial short: %q", s)
+	}
+	h := RedactHash([]byte("key"))
+	if h("secret") != h("secret") || h("secret") == h("other") {
+		t.Error("hash doesn't correlate")
+	}
+}
+
+func TestRedactHandler(t *testing.T) {
+	var redacted, original bytes.Buffer
+	var hdr interface{}
+	h := MultiHandler(
+		RedactHandler(MultiHandler(
+			NewStdFormatter(&redacted, "", 0),
+			HandlerFunc(func(e Event) error { hdr = e.Data[3]; return nil })),
+			RedactValues(RedactBearerToken, RedactEmail)),
+		NewStdFormatter(&original, "", 0))
+	l := NewLogger(syslog.LOG_DEBUG, h)
+
+	auth := KV{"Authorization": "Basic xyz"}
+	l.With("Password", "hunter2").INFO("login by joe@example.com",
+		"hdr", auth,
+		"err", errors.New("bad bearer abc.def"),
+		"lazy", Lazy(func() interface{} { return "mail bob@example.com" }),
+		"user", "joe",
+		Group("db", "password", "x"))
+
+	want := `login by [REDACTED] Password=[REDACTED] hdr="unsupported value type" err="bad [REDACTED]" lazy="mail [REDACTED]" user=joe db.password=[REDACTED]` + "\n"
+	if redacted.String() != want {
+		t.Errorf("got  %q\nwant %q", redacted.String(), want)
+	}
+	if kv, ok := hdr.(KV); !ok || kv["Authorization"] != "[REDACTED]" {
+		t.Errorf("KV not redacted: %v", hdr)
+	}
+	if auth["Authorization"] != "Basic xyz" {
+		t.Errorf("KV modified: %v", auth)
+	}
+	want = `login by joe@example.com Password=hunter2 hdr="unsupported value type" err="bad bearer abc.def" lazy="mail bob@example.com" user=joe db.password=x` + "\n"
+	if original.String() != want {
+		t.Errorf("original modified: %q", original.String())
+	}
+}
+
+func TestRedactCreditCard(t *testing.T) {
+	var buf bytes.Buffer
+	var redacted, original time.Time
+	h := MultiHandler(
+		RedactHandler(MultiHandler(
+			NewStdFormatter(&buf, "", 0),
+			HandlerFunc(func(e Event) error { redacted = e.Time(); return nil })),
+			RedactValues(RedactCreditCard), RedactWith(RedactPartial(4))),
+		HandlerFunc(func(e Event) error { original = e.Time(); return nil }))
+	l := NewLogger(syslog.LOG_DEBUG, h)
+
+	type payment struct{ Card string }
+	l.INFO("paid with 4111 1111 1111 1111", "card", payment{"4111111111111111"}, "n", 4111111111111111, "amount", 12)
+
+	want := `paid with ***************1111 card={************1111} n=************1111 amount=12` + "\n"
+	if buf.String() != want {
+		t.Errorf("got  %q\nwant %q", buf.String(), want)
+	}
+	if !redacted.Equal(original) {
+		t.Errorf("redacted copy has another timestamp: %v != %v", redacted, original)
+	}
+}
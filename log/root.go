@@ -22,6 +22,7 @@ func init() {
 	// Default Logger is an ordinary stdlib like logger, to be compatible
 	defaultLogger = New(os.Stderr, "", LstdFlags)
 	man = newManager(defaultLogger)
+	configureFromEnv()
 }
 
 // Sets the default logger to the minimal mode, where it doesn't log timestamps
@@ -38,6 +39,12 @@ func With(kv ...interface{}) *Logger {
 	return defaultLogger.With(kv...)
 }
 
+// CallerSkip creates a child Logger of the default logger skipping n additional
+// stack frames when recording code info.
+func CallerSkip(n int) *Logger {
+	return defaultLogger.WithCallerSkip(n)
+}
+
 // AutoColoring turns on coloring if the output Writer is connected to a TTY
 func AutoColoring() {
 	defaultLogger.AutoColoring()
@@ -108,6 +115,15 @@ func DEBUG(msg string, kv ...interface{}) {
 	}
 }
 
+// Requests the default logger to create a log event
+func TRACE(msg string, kv ...interface{}) {
+	c := defaultLogger
+	l := LvlTRACE
+	if c.Does(l) {
+		c.log(l, msg, kv...)
+	}
+}
+
 // If the default Logger is logging at the requested level a function creating such a log event will be returned.
 func ALERTok() (ilog.LogFunc, bool) { l := defaultLogger; return l.alert, l.Does(syslog.LOG_ALERT) }
 
@@ -129,6 +145,9 @@ func INFOok() (ilog.LogFunc, bool) { l := defaultLogger; return l.info, l.Does(s
 // If the default Logger is logging at the requested level a function creating such a log event will be returned.
 func DEBUGok() (ilog.LogFunc, bool) { l := defaultLogger; return l.debug, l.Does(syslog.LOG_DEBUG) }
 
+// If the default Logger is logging at the requested level a function creating such a log event will be returned.
+func TRACEok() (ilog.LogFunc, bool) { l := defaultLogger; return l.trace, l.Does(LvlTRACE) }
+
 //---
 
 // Increase the log level of the default Logger
@@ -165,6 +184,12 @@ func Level() syslog.Priority {
 	return defaultLogger.Level()
 }
 
+// SetErrorHandler sets the ErrorHandler of the default Logger, which is used
+// for all Loggers in the name hierarchy without their own.
+func SetErrorHandler(fn ErrorHandler) {
+	defaultLogger.SetErrorHandler(fn)
+}
+
 //--- std logger stuff
 
 // Compatible with the standard library
@@ -200,7 +225,7 @@ func Fatal(v ...interface{}) {
 		s := fmt.Sprint(v...)
 		c.log(l, s)
 	}
-	os.Exit(1)
+	exit()
 }
 
 // Compatible with the standard library
@@ -211,7 +236,7 @@ func Fatalf(format string, v ...interface{}) {
 		s := fmt.Sprintf(format, v...)
 		c.log(l, s)
 	}
-	os.Exit(1)
+	exit()
 
 }
 
@@ -223,7 +248,7 @@ func Fatalln(v ...interface{}) {
 		s := fmt.Sprintln(v...)
 		c.log(l, s)
 	}
-	os.Exit(1)
+	exit()
 }
 
 // Compatible with the standard library
@@ -233,7 +258,7 @@ func Panic(v ...interface{}) {
 	if c.Does(l) {
 		s := fmt.Sprint(v...)
 		c.log(l, s)
-		panic(s)
+		panic(panicLogged(s))
 	}
 }
 
@@ -244,7 +269,7 @@ func Panicf(format string, v ...interface{}) {
 	if c.Does(l) {
 		s := fmt.Sprintf(format, v...)
 		c.log(l, s)
-		panic(s)
+		panic(panicLogged(s))
 	}
 }
 
@@ -255,7 +280,7 @@ func Panicln(v ...interface{}) {
 	if c.Does(l) {
 		s := fmt.Sprintln(v...)
 		c.log(l, s)
-		panic(s)
+		panic(panicLogged(s))
 	}
 }
 
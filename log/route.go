@@ -0,0 +1,172 @@
+package log
+
+import (
+	"github.com/One-com/gonelog/syslog"
+	"strings"
+)
+
+// RouteMode decides whether a RouteHandler sends events to only the first or all matching routes.
+type RouteMode int
+
+const (
+	RouteFirst RouteMode = iota // Send the event to the first matching route only.
+	RouteAll                    // Send the event to all matching routes.
+)
+
+// Route is a condition for sending events to a Handler. Create it with NewRoute()
+type Route struct {
+	h Handler
+
+	name   []string // name pattern split in segments. nil matches all names.
+	anyLvl bool
+	minLvl syslog.Priority // most severe level
+	maxLvl syslog.Priority // least severe level
+	keys   []string        // keys which must be present in the event data
+}
+
+// RouteCond adds a condition to a Route
+type RouteCond func(*Route)
+
+// NewRoute creates a Route to the Handler for events satisfying all the conditions.
+// With no conditions all events are routed.
+func NewRoute(h Handler, conds ...RouteCond) Route {
+	r := Route{h: h, anyLvl: true}
+	for _, cond := range conds {
+		cond(&r)
+	}
+	return r
+}
+
+// RouteName matches the Logger name of the event against a "/" separated glob pattern.
+// Within a segment '*' matches any sequence of characters and '?' a single character.
+// A "**" segment matches zero or more segments. So "net/http/*" matches Loggers
+// directly below "net/http", while "db/**" matches "db" and all Loggers below it.
+// The empty pattern matches the root Logger.
+func RouteName(pattern string) RouteCond {
+	return func(r *Route) {
+		r.name = strings.Split(pattern, "/")
+	}
+}
+
+// RouteLevel matches events with a level from min to max (inclusive),
+// where min is the most severe level. Like RouteLevel(syslog.LOG_EMERG, syslog.LOG_WARN)
+func RouteLevel(min, max syslog.Priority) RouteCond {
+	return func(r *Route) {
+		r.anyLvl = false
+		r.minLvl, r.maxLvl = min, max
+	}
+}
+
+// RouteHasKey matches events having all the keys in their key/value data.
+func RouteHasKey(keys ...string) RouteCond {
+	return func(r *Route) {
+		r.keys = append(r.keys, keys...)
+	}
+}
+
+func (r *Route) match(e Event) bool {
+	if !r.anyLvl && (e.Lvl < r.minLvl || e.Lvl > r.maxLvl) {
+		return false
+	}
+	if r.name != nil && !matchSegments(r.name, e.Name) {
+		return false
+	}
+	for _, key := range r.keys {
+		if !hasKey(e.Data, key) {
+			return false
+		}
+	}
+	return true
+}
+
+func hasKey(keyvals []interface{}, key string) bool {
+	for i := 0; i < len(keyvals); i += 2 {
+		if k, ok := keyvals[i].(string); ok && k == key {
+			return true
+		}
+	}
+	return false
+}
+
+// matchSegments matches the "/" separated name against the pattern segments
+// without allocating.
+func matchSegments(pattern []string, name string) bool {
+	if len(pattern) == 0 {
+		return name == ""
+	}
+	if pattern[0] == "**" {
+		if matchSegments(pattern[1:], name) {
+			return true
+		}
+		for i := 0; i < len(name); i++ {
+			if name[i] == '/' && matchSegments(pattern[1:], name[i+1:]) {
+				return true
+			}
+		}
+		return len(pattern) == 1 // "**" at the end matches everything
+	}
+	seg := name
+	rest := ""
+	last := true
+	if i := strings.IndexByte(name, '/'); i >= 0 {
+		seg, rest, last = name[:i], name[i+1:], false
+	}
+	if !globMatch(pattern[0], seg) {
+		return false
+	}
+	if last {
+		return len(pattern) == 1 || (len(pattern) == 2 && pattern[1] == "**")
+	}
+	return matchSegments(pattern[1:], rest)
+}
+
+type routeHandler struct {
+	mode   RouteMode
+	routes []Route
+}
+
+// RouteHandler sends events to the Handlers of the matching routes, in order.
+// Events matching no route are discarded.
+// In RouteAll mode the errors of the failing routes are returned like MultiHandler does:
+// A single error if no route logged the event, else a *MultiError.
+func RouteHandler(mode RouteMode, routes ...Route) Handler {
+	r := make([]Route, len(routes))
+	copy(r, routes)
+	return &routeHandler{mode: mode, routes: r}
+}
+
+func (h *routeHandler) Chained() []interface{} {
+	c := make([]interface{}, len(h.routes))
+	for i := range h.routes {
+		c[i] = h.routes[i].h
+	}
+	return c
+}
+
+func (h *routeHandler) Log(e Event) error {
+	var errs []error
+	var logged bool
+	for i := range h.routes {
+		r := &h.routes[i]
+		if !r.match(e) {
+			continue
+		}
+		err := r.h.Log(e)
+		if h.mode == RouteFirst {
+			return err
+		}
+		if err != nil {
+			errs = append(errs, wrapHandlerError(r.h, err))
+		}
+		if err == nil || partlyLogged(err) {
+			logged = true
+		}
+	}
+	switch {
+	case len(errs) == 0:
+		return nil
+	case len(errs) == 1 && !logged:
+		return errs[0]
+	}
+	return &MultiError{Errors: errs, Logged: logged}
+}
@@ -0,0 +1,117 @@
+package log
+
+import (
+	"bytes"
+	"errors"
+	"github.com/One-com/gonelog/syslog"
+	"strings"
+	"testing"
+)
+
+func TestMatchSegments(t *testing.T) {
+	tests := []struct {
+		pattern, name string
+		match         bool
+	}{
+		{"net/http/*", "net/http/server", true},
+		{"net/http/*", "net/http", false},
+		{"net/http/*", "net/http/server/conn", false},
+		{"db/**", "db", true},
+		{"db/**", "db/pool/conn", true},
+		{"db/**", "dbx", false},
+		{"**/conn", "db/pool/conn", true},
+		{"**/conn", "conn", true},
+		{"**/conn", "db/pool", false},
+		{"a/*/c", "a/b/c", true},
+		{"a/**/c", "a/c", true},
+		{"a/**/c", "a/b/b/c", true},
+		{"my?ib", "mylib", true},
+		{"", "", true},
+		{"", "x", false},
+	}
+	for _, test := range tests {
+		if m := matchSegments(strings.Split(test.pattern, "/"), test.name); m != test.match {
+			t.Errorf("matchSegments(%q, %q) = %v", test.pattern, test.name, m)
+		}
+	}
+}
+
+func TestRouteHandler(t *testing.T) {
+	var got []string
+	rec := func(tag string) Handler {
+		return HandlerFunc(func(e Event) error {
+			got = append(got, tag+":"+e.Msg)
+			return nil
+		})
+	}
+	routes := []Route{
+		NewRoute(rec("http"), RouteName("net/http/*"), RouteLevel(syslog.LOG_EMERG, syslog.LOG_WARN)),
+		NewRoute(rec("user"), RouteName("db/**"), RouteHasKey("user_id")),
+		NewRoute(rec("all")),
+	}
+
+	for _, mode := range []RouteMode{RouteFirst, RouteAll} {
+		got = nil
+		h := RouteHandler(mode, routes...)
+		http := NewLogger(syslog.LOG_DEBUG, h)
+		http.name = "net/http/server"
+		db := NewLogger(syslog.LOG_DEBUG, h)
+		db.name = "db/pool"
+
+		http.ERROR("e1")
+		http.INFO("i1")
+		db.INFO("i2", "user_id", 42)
+		db.INFO("i3")
+		want := "http:e1 all:i1 user:i2 all:i3"
+		if mode == RouteAll {
+			want = "http:e1 all:e1 all:i1 user:i2 all:i2 all:i3"
+		}
+		if s := strings.Join(got, " "); s != want {
+			t.Errorf("mode %d: got %q, want %q", mode, s, want)
+		}
+	}
+}
+
+func TestRouteHandlerErrors(t *testing.T) {
+	broken := errors.New("broken")
+	var buf bytes.Buffer
+	root := newLogger("")
+	root.SetHandler(NewMinFormatter(&buf))
+	l := newLogger("routes")
+	l.h.SwapParent(root)
+	var reported []error
+	l.SetErrorHandler(func(h Handler, e Event, err error) {
+		reported = append(reported, err)
+	})
+
+	var ok bytes.Buffer
+	l.SetHandler(RouteHandler(RouteAll,
+		NewRoute(HandlerFunc(func(e Event) error { return broken })),
+		NewRoute(NewMinFormatter(&ok))))
+	l.INFO("partly")
+	if ok.String() != "<6>partly\n" || buf.Len() != 0 {
+		t.Errorf("logged twice: %q %q", ok.String(), buf.String())
+	}
+	if len(reported) != 1 || reported[0] != broken {
+		t.Errorf("wrong reports: %v", reported)
+	}
+
+	// No route logged it
+	l.SetHandler(RouteHandler(RouteAll, NewRoute(HandlerFunc(func(e Event) error { return broken }))))
+	l.INFO("failed")
+	if buf.String() != "<6>failed\n" {
+		t.Errorf("parent didn't log: %q", buf.String())
+	}
+	if s := l.Stats(); s.Handled != 2 || s.Failed != 2 || s.Dropped != 0 {
+		t.Errorf("wrong stats: %+v", s)
+	}
+}
+
+func TestRouteHandlerAllocs(t *testing.T) {
+	h := RouteHandler(RouteFirst,
+		NewRoute(HandlerFunc(func(e Event) error { return nil }), RouteName("db/**/conn"), RouteHasKey("id")))
+	e := Event{&event{Lvl: syslog.LOG_INFO, Name: "db/pool/x/conn", Data: []interface{}{"id", 1}}}
+	if n := testing.AllocsPerRun(100, func() { h.Log(e) }); n != 0 {
+		t.Errorf("%f allocations per routed event", n)
+	}
+}
@@ -0,0 +1,150 @@
+package log
+
+import (
+	"context"
+	"io"
+	"os"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// Flusher is implemented by Handlers and Writers buffering log output.
+// Shutdown() calls Flush() before exiting.
+type Flusher interface {
+	Flush() error
+}
+
+// Chainer is implemented by Handlers and Writers passing events or data on to other
+// Handlers or Writers (a formatter to its Writer, a MultiHandler to its Handlers).
+// It lets Shutdown() find everything reachable from the Loggers to flush and close it.
+type Chainer interface {
+	Chained() []interface{}
+}
+
+// ShutdownTimeout is the time Fatal*() waits for Shutdown() before exiting.
+var ShutdownTimeout = 5 * time.Second
+
+type exitHandler struct {
+	fn func(ctx context.Context)
+}
+
+var (
+	exitmu       sync.Mutex
+	exitHandlers []*exitHandler
+)
+
+// RegisterExitHandler registers a function to be called by Shutdown(), and thereby
+// before Fatal*() exits the process. Use it to flush other buffered output:
+//
+//	unregister := log.RegisterExitHandler(func(ctx context.Context) { w.Flush() })
+//
+// Exit handlers are called in reverse order of registration, before the log Handlers
+// are flushed and closed. They should respect the deadline of ctx.
+// Running metric Clients are registered to be stopped (flushing their metrics).
+// The returned function removes the exit handler again.
+func RegisterExitHandler(fn func(ctx context.Context)) (unregister func()) {
+	h := &exitHandler{fn: fn}
+	exitmu.Lock()
+	exitHandlers = append(exitHandlers, h)
+	exitmu.Unlock()
+	return func() {
+		exitmu.Lock()
+		defer exitmu.Unlock()
+		for i, eh := range exitHandlers {
+			if eh == h {
+				exitHandlers = append(exitHandlers[:i:i], exitHandlers[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// Shutdown calls the registered exit handlers and then flushes and closes all Handlers
+// and Writers reachable from the Loggers in the name hierarchy (including the default Logger).
+// Handlers and Writers are flushed if they implement Flusher and closed if they implement
+// io.Closer. *os.File Writers are left alone.
+// Unnamed Loggers (from NewLogger()) are not reachable. Use an exit handler for their Handlers.
+// It returns the first error encountered, or ctx.Err() if the context expires before
+// everything is done.
+func Shutdown(ctx context.Context) error {
+	exitmu.Lock()
+	fns := make([]*exitHandler, len(exitHandlers))
+	copy(fns, exitHandlers)
+	exitmu.Unlock()
+	var handlers []interface{}
+	for _, l := range man.loggers() {
+		handlers = append(handlers, l.Handler())
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- shutdown(ctx, fns, handlers)
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// shutdown calls the exit handlers and flushes and closes everything reachable from handlers.
+func shutdown(ctx context.Context, fns []*exitHandler, handlers []interface{}) (err error) {
+	for i := len(fns) - 1; i >= 0; i-- {
+		fns[i].fn(ctx)
+	}
+
+	// Gather everything reachable, handlers before what they pass data on to.
+	var nodes []interface{}
+	seen := make(map[interface{}]bool)
+	var walk func(n interface{})
+	walk = func(n interface{}) {
+		if n == nil {
+			return
+		}
+		// Only pointers can create cycles, and they are always safe as map keys
+		if reflect.ValueOf(n).Kind() == reflect.Ptr {
+			if seen[n] {
+				return
+			}
+			seen[n] = true
+		}
+		nodes = append(nodes, n)
+		if c, ok := n.(Chainer); ok {
+			for _, child := range c.Chained() {
+				walk(child)
+			}
+		}
+	}
+	for _, h := range handlers {
+		walk(h)
+	}
+
+	for _, n := range nodes {
+		if f, ok := n.(Flusher); ok {
+			if ferr := f.Flush(); ferr != nil && err == nil {
+				err = ferr
+			}
+		}
+	}
+	for _, n := range nodes {
+		if _, ok := n.(*os.File); ok {
+			continue
+		}
+		if c, ok := n.(io.Closer); ok {
+			if cerr := c.Close(); cerr != nil && err == nil {
+				err = cerr
+			}
+		}
+	}
+	return
+}
+
+// exit shuts down logging and exits the process with status 1.
+func exit() {
+	ctx, cancel := context.WithTimeout(context.Background(), ShutdownTimeout)
+	Shutdown(ctx)
+	cancel()
+	os.Exit(1)
+}
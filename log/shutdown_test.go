@@ -0,0 +1,78 @@
+package log
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/One-com/gonelog/syslog"
+	"testing"
+	"time"
+)
+
+type bufWriter struct {
+	bytes.Buffer
+	out     *bytes.Buffer
+	flushed bool
+	closed  bool
+}
+
+func (b *bufWriter) Flush() error {
+	b.flushed = true
+	_, err := b.WriteTo(b.out)
+	return err
+}
+
+func (b *bufWriter) Close() error {
+	b.closed = true
+	return errors.New("close failed")
+}
+
+func TestShutdown(t *testing.T) {
+	var out bytes.Buffer
+	w := &bufWriter{out: &out}
+	l := NewLogger(syslog.LOG_INFO, MultiHandler(
+		FilterHandler(func(e Event) bool { return true }, NewStdFormatter(SyncWriter(w), "", 0)),
+		NewJSONFormatter(LevelFilterWriter(syslog.LOG_DEBUG, w))))
+
+	var exited bool
+	fns := []*exitHandler{{fn: func(ctx context.Context) { exited = true }}}
+
+	l.ERROR("buffered")
+	if out.Len() != 0 {
+		t.Fatal("not buffered")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	err := shutdown(ctx, fns, []interface{}{l.Handler()})
+	if err == nil || err.Error() != "close failed" {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if !exited || !w.flushed || !w.closed {
+		t.Errorf("exit handler called: %v, flushed: %v, closed: %v", exited, w.flushed, w.closed)
+	}
+	if out.String()[:9] != "buffered\n" {
+		t.Errorf("output not flushed: %q", out.String())
+	}
+}
+
+func TestRegisterExitHandler(t *testing.T) {
+	registered := func(n int) bool {
+		exitmu.Lock()
+		defer exitmu.Unlock()
+		return len(exitHandlers) == n
+	}
+	exitmu.Lock()
+	n := len(exitHandlers)
+	exitmu.Unlock()
+
+	unregister := RegisterExitHandler(func(ctx context.Context) {})
+	if !registered(n + 1) {
+		t.Error("exit handler not registered")
+	}
+	unregister()
+	unregister()
+	if !registered(n) {
+		t.Error("exit handler not unregistered")
+	}
+}
@@ -1,10 +1,12 @@
 package log
 
 import (
+	"bytes"
 	"github.com/One-com/gonelog/syslog"
 	"io"
 	"log"
 	"regexp"
+	"strconv"
 	"strings"
 )
 
@@ -32,10 +34,14 @@ func (w StdlibWriter) Write(p []byte) (int, error) {
 type StdlibAdapter struct {
 	parse        bool // attempt to parse STDLIB log output to re-create event
 	level        syslog.Priority
+	levels       map[string]syslog.Priority // level prefixes to detect. nil for no detection
 	gonelogger   *Logger
 	timestampKey string
 	fileKey      string
 	messageKey   string
+	stdFlags     int    // flags of the stdlib logger, its header is removed
+	stdPrefix    string // prefix of the stdlib logger, removed
+	strip        bool
 }
 
 // StdlibAdapterOption sets a parameter for the StdlibAdapter.
@@ -56,13 +62,51 @@ func MessageKey(key string) StdlibAdapterOption {
 	return func(a *StdlibAdapter) { a.messageKey = key }
 }
 
-// MessageKey sets the key for the actual log message. By default, it's "msg".
+// Parse makes the adapter parse the date, time and file written by the stdlib
+// logger and pass them on as key/value data.
 func Parse() StdlibAdapterOption {
 	return func(a *StdlibAdapter) { a.parse = true }
 }
 
+// DefaultLevelPrefixes maps the level names recognized by DetectLevel() to levels.
+var DefaultLevelPrefixes = map[string]syslog.Priority{
+	"EMERG":    syslog.LOG_EMERG,
+	"PANIC":    syslog.LOG_EMERG,
+	"ALERT":    syslog.LOG_ALERT,
+	"FATAL":    syslog.LOG_ALERT,
+	"CRIT":     syslog.LOG_CRIT,
+	"CRITICAL": syslog.LOG_CRIT,
+	"ERR":      syslog.LOG_ERR,
+	"ERROR":    syslog.LOG_ERR,
+	"WARN":     syslog.LOG_WARN,
+	"WARNING":  syslog.LOG_WARN,
+	"NOTICE":   syslog.LOG_NOTICE,
+	"INFO":     syslog.LOG_INFO,
+	"DEBUG":    syslog.LOG_DEBUG,
+	"TRACE":    LvlTRACE,
+}
+
+// DetectLevel makes the adapter look for a level prefix of the message, like
+// "[ERROR] ...", "WARN: ..." or "<3>...". The prefix is removed from the message
+// and the event is generated with the level, if the Logger does it.
+// Names (case-insensitive) are looked up in mapping, or DefaultLevelPrefixes if nil.
+// "<N>" is always taken as a syslog severity.
+func DetectLevel(mapping map[string]syslog.Priority) StdlibAdapterOption {
+	return func(a *StdlibAdapter) {
+		if mapping == nil {
+			mapping = DefaultLevelPrefixes
+		}
+		a.levels = make(map[string]syslog.Priority, len(mapping))
+		for k, v := range mapping {
+			a.levels[strings.ToUpper(k)] = v
+		}
+	}
+}
+
 // NewStdlibAdapter returns a new StdlibAdapter wrapper around the passed
 // logger. It's designed to be passed to log.SetOutput.
+// Lines are logged at level, unless another level is found by DetectLevel(),
+// if the Logger does that level.
 func NewStdlibAdapter(logger *Logger, level syslog.Priority, options ...StdlibAdapterOption) io.Writer {
 	a := StdlibAdapter{
 		level:        level,
@@ -77,11 +121,22 @@ func NewStdlibAdapter(logger *Logger, level syslog.Priority, options ...StdlibAd
 	return a
 }
 
+// stdlibHeader makes the adapter remove the prefix and header written by a
+// stdlib logger with the given flags and prefix.
+func stdlibHeader(flags int, prefix string) StdlibAdapterOption {
+	return func(a *StdlibAdapter) {
+		a.stdFlags, a.stdPrefix, a.strip = flags, prefix, true
+	}
+}
+
 func (a StdlibAdapter) Write(p []byte) (int, error) {
+	if a.strip {
+		p = a.stripHeader(p)
+	}
 	var msg string
+	var keyvals []interface{}
 	if a.parse {
 		result := subexps(p)
-		keyvals := []interface{}{}
 		var timestamp string
 		if date, ok := result["date"]; ok && date != "" {
 			timestamp = date
@@ -102,14 +157,134 @@ func (a StdlibAdapter) Write(p []byte) (int, error) {
 		msg = result["msg"]
 
 	} else {
-		msg = string(p)
+		msg = strings.TrimSuffix(string(p), "\n")
 	}
-	if err := a.gonelogger.log(a.level, msg); err != nil {
+	lvl := a.level
+	if a.levels != nil {
+		if l, rest, ok := a.detectLevel(msg); ok {
+			lvl, msg = l, rest
+		}
+	}
+	if !a.gonelogger.Does(lvl) {
+		return len(p), nil
+	}
+	if err := a.gonelogger.log(lvl, msg, keyvals...); err != nil {
 		return 0, err
 	}
 	return len(p), nil
 }
 
+// detectLevel finds a level prefix of msg, returning the level and the rest of msg.
+func (a StdlibAdapter) detectLevel(msg string) (lvl syslog.Priority, rest string, ok bool) {
+	var name string
+	switch {
+	case strings.HasPrefix(msg, "<"):
+		if i := strings.IndexByte(msg, '>'); i > 1 {
+			if n, err := strconv.Atoi(msg[1:i]); err == nil && n >= 0 && n <= int(syslog.LOG_DEBUG) {
+				return syslog.Priority(n), strings.TrimLeft(msg[i+1:], " "), true
+			}
+		}
+		return
+	case strings.HasPrefix(msg, "["):
+		i := strings.IndexByte(msg, ']')
+		if i < 0 {
+			return
+		}
+		name, rest = msg[1:i], msg[i+1:]
+	default:
+		i := strings.IndexByte(msg, ':')
+		if i < 0 || strings.IndexByte(msg[:i], ' ') >= 0 {
+			return
+		}
+		name, rest = msg[:i], msg[i+1:]
+	}
+	lvl, ok = a.levels[strings.ToUpper(name)]
+	return lvl, strings.TrimLeft(rest, " "), ok
+}
+
+// stripHeader removes what the stdlib logger wrote before the message.
+// The line is returned unchanged if it doesn't match the flags.
+func (a StdlibAdapter) stripHeader(p []byte) []byte {
+	line := p
+	if a.stdFlags&log.Lmsgprefix == 0 {
+		if !bytes.HasPrefix(line, []byte(a.stdPrefix)) {
+			return p
+		}
+		line = line[len(a.stdPrefix):]
+	}
+	var n int // length of date/time
+	if a.stdFlags&log.Ldate != 0 {
+		n += len("2009/01/23 ")
+	}
+	if a.stdFlags&(log.Ltime|log.Lmicroseconds) != 0 {
+		n += len("01:23:23 ")
+		if a.stdFlags&log.Lmicroseconds != 0 {
+			n += len(".123123")
+		}
+	}
+	if len(line) < n {
+		return p
+	}
+	line = line[n:]
+	if a.stdFlags&(log.Lshortfile|log.Llongfile) != 0 {
+		m := stdlibFileRegexp.FindIndex(line)
+		if m == nil {
+			return p
+		}
+		line = line[m[1]:]
+	}
+	if a.stdFlags&log.Lmsgprefix != 0 {
+		if !bytes.HasPrefix(line, []byte(a.stdPrefix)) {
+			return p
+		}
+		line = line[len(a.stdPrefix):]
+	}
+	return line
+}
+
+var stdlibFileRegexp = regexp.MustCompile(`^.*?:[0-9]+: `)
+
+// CaptureStdlib redirects the output of the stdlib default logger to the Logger,
+// with level detection like DetectLevel(nil). Lines without a level prefix are
+// logged at the default level of the Logger. Lines at levels the Logger doesn't do are dropped.
+// The stdlib flags and prefix are left alone. Instead the header written by the stdlib
+// logger is removed and the events are generated by a child Logger doing the same (with the
+// caller of the stdlib log function as the caller): Lshortfile/Llongfile record code info,
+// Ldate/Ltime/Lmicroseconds timestamp the events and these flags (and LUTC) are set on a clone
+// of the current Handler with the prefix. The Logger itself is not changed.
+// Changing the stdlib flags or prefix while captured is not supported.
+// Returns a function restoring the stdlib default logger output.
+func CaptureStdlib(l *Logger, options ...StdlibAdapterOption) (restore func()) {
+	out, flags, prefix := log.Writer(), log.Flags(), log.Prefix()
+
+	// skip the adapter Write() and the stdlib output functions.
+	c := l.WithCallerSkip(2)
+	if flags&(Ldate|Ltime|Lmicroseconds) != 0 {
+		c.do |= maskDoTime
+	}
+	if flags&(Llongfile|Lshortfile) != 0 {
+		c.do |= maskDoCode
+	}
+
+	// The child gets its own swapper with the clone, falling back to the Logger.
+	const stdFlags = Ldate | Ltime | Lmicroseconds | Llongfile | Lshortfile | LUTC
+	c.h = newSwapper()
+	c.h.SwapParent(l)
+	c.h.SwapHandler(l.Handler())
+	if flags&stdFlags != 0 {
+		c.h.SetFlags(c.h.Flags()&^stdFlags | flags&stdFlags)
+	}
+	if prefix != "" {
+		c.h.SetPrefix(prefix)
+	}
+
+	opts := append([]StdlibAdapterOption{DetectLevel(nil), stdlibHeader(flags, prefix)}, options...)
+	log.SetOutput(NewStdlibAdapter(c, l.DefaultLevel(), opts...))
+	return func() {
+		log.SetOutput(out)
+	}
+}
+
 const (
 	logRegexpDate = `(?P<date>[0-9]{4}/[0-9]{2}/[0-9]{2})?[ ]?`
 	logRegexpTime = `(?P<time>[0-9]{2}:[0-9]{2}:[0-9]{2}(\.[0-9]+)?)?[ ]?`
@@ -0,0 +1,146 @@
+package log
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/One-com/gonelog/syslog"
+	"log"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestStdlibAdapterParse(t *testing.T) {
+	rec := &eventRecorder{}
+	l := NewLogger(syslog.LOG_INFO, rec)
+	w := NewStdlibAdapter(l, syslog.LOG_NOTICE, Parse(), DetectLevel(nil))
+
+	w.Write([]byte("2017/01/02 15:04:05 main.go:12: [ERROR] disk full\n"))
+	w.Write([]byte("2017/01/02 15:04:05 plain message\n"))
+	w.Write([]byte("<7>debug message\n")) // not done by the Logger
+
+	if len(rec.events) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(rec.events))
+	}
+	e, kv := rec.events[0], rec.data[0]
+	if e.Lvl != syslog.LOG_ERR || e.Msg != "disk full" {
+		t.Errorf("unexpected event: %d %q", e.Lvl, e.Msg)
+	}
+	if len(kv) != 4 || kv[1] != "2017/01/02 15:04:05" || kv[3] != "main.go:12" {
+		t.Errorf("unexpected data: %v", kv)
+	}
+	if e := rec.events[1]; e.Lvl != syslog.LOG_NOTICE || e.Msg != "plain message" {
+		t.Errorf("unexpected event: %d %q", e.Lvl, e.Msg)
+	}
+}
+
+func TestDetectLevel(t *testing.T) {
+	a := StdlibAdapter{}
+	DetectLevel(map[string]syslog.Priority{"oops": syslog.LOG_CRIT})(&a)
+	tests := []struct {
+		msg  string
+		lvl  syslog.Priority
+		rest string
+		ok   bool
+	}{
+		{"[Oops] bad", syslog.LOG_CRIT, "bad", true},
+		{"OOPS: bad", syslog.LOG_CRIT, "bad", true},
+		{"<2> bad", syslog.LOG_CRIT, "bad", true},
+		{"[ERROR] bad", 0, "", false},
+		{"some oops: bad", 0, "", false},
+		{"<9>bad", 0, "", false},
+	}
+	for _, test := range tests {
+		lvl, rest, ok := a.detectLevel(test.msg)
+		if ok != test.ok || (ok && (lvl != test.lvl || rest != test.rest)) {
+			t.Errorf("%q: got %d %q %v", test.msg, lvl, rest, ok)
+		}
+	}
+}
+
+func TestCaptureStdlib(t *testing.T) {
+	defer log.SetFlags(log.Flags())
+	defer log.SetPrefix(log.Prefix())
+	log.SetFlags(log.LstdFlags | log.Lshortfile)
+	log.SetPrefix("app: ")
+
+	rec := &eventRecorder{}
+	l := NewLogger(syslog.LOG_INFO, rec)
+	restore := CaptureStdlib(l)
+	if l.DoingTime() || l.DoingCodeInfo() {
+		t.Error("Logger changed")
+	}
+	_, _, line, _ := runtime.Caller(0)
+	log.Printf("WARN: captured %d", 1)
+	log.Print("print")
+	l.SetLevel(syslog.LOG_NOTICE)
+	log.Print("not done")
+	log.Print("DEBUG: not done")
+	restore()
+
+	if log.Flags() != log.LstdFlags|log.Lshortfile || log.Prefix() != "app: " {
+		t.Errorf("stdlib logger changed: %d %q", log.Flags(), log.Prefix())
+	}
+	if len(rec.events) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(rec.events))
+	}
+	e := rec.events[0]
+	if e.Lvl != syslog.LOG_WARN || e.Msg != "captured 1" {
+		t.Errorf("unexpected event: %d %q", e.Lvl, e.Msg)
+	}
+	if file, fline := e.FileInfo(); filepath.Base(file) != "stdgw_test.go" || fline != line+1 {
+		t.Errorf("wrong caller info: %s:%d, want stdgw_test.go:%d", file, fline, line+1)
+	}
+	if e.Time().IsZero() {
+		t.Error("no timestamp")
+	}
+	if e := rec.events[1]; e.Lvl != LvlDEFAULT || e.Msg != "print" {
+		t.Errorf("unexpected event: %d %q", e.Lvl, e.Msg)
+	}
+}
+
+func TestCaptureStdlibFlags(t *testing.T) {
+	defer log.SetFlags(log.Flags())
+	defer log.SetPrefix(log.Prefix())
+	log.SetFlags(log.Lshortfile | log.Lmsgprefix)
+	log.SetPrefix("app: ")
+
+	var buf bytes.Buffer
+	l := NewLogger(syslog.LOG_INFO, NewStdFormatter(&buf, "", Llevel))
+	restore := CaptureStdlib(l)
+	if l.Flags() != Llevel || l.Prefix() != "" || l.DoingCodeInfo() {
+		t.Errorf("Logger changed: %d %q %v", l.Flags(), l.Prefix(), l.DoingCodeInfo())
+	}
+	_, _, line, _ := runtime.Caller(0)
+	log.Print("ERROR: failed")
+	l.ERROR("direct")
+	restore()
+
+	if want := fmt.Sprintf("<3>app: stdgw_test.go:%d: failed\n<3>direct\n", line+1); buf.String() != want {
+		t.Errorf("got %q, want %q", buf.String(), want)
+	}
+}
+
+func TestStripHeader(t *testing.T) {
+	tests := []struct {
+		flags  int
+		prefix string
+		line   string
+		want   string
+	}{
+		{0, "", "msg\n", "msg\n"},
+		{log.LstdFlags, "", "2009/01/23 01:23:23 msg\n", "msg\n"},
+		{log.LstdFlags | log.Lmicroseconds, "p ", "p 2009/01/23 01:23:23.123123 msg\n", "msg\n"},
+		{log.Ltime | log.Llongfile, "", "01:23:23 /a/b:c/d.go:23: msg: x\n", "msg: x\n"},
+		{log.Lshortfile | log.Lmsgprefix, "p: ", "d.go:23: p: msg\n", "msg\n"},
+		{log.Lshortfile, "", "no file info\n", "no file info\n"},
+		{0, "p: ", "other: msg\n", "other: msg\n"},
+	}
+	for _, test := range tests {
+		var a StdlibAdapter
+		stdlibHeader(test.flags, test.prefix)(&a)
+		if got := string(a.stripHeader([]byte(test.line))); got != test.want {
+			t.Errorf("%d %q %q: got %q, want %q", test.flags, test.prefix, test.line, got, test.want)
+		}
+	}
+}
@@ -15,8 +15,9 @@ var ErrNotLogged = errors.New("No handler found to log event")
 
 // Using atomic and mutex to support atomic reads, but also read-modify-write ops.
 type swapper struct {
-	mu  sync.Mutex // Locked by any who want to modify the valueStruct
-	val atomic.Value
+	stats counters   // first to be 64-bit aligned for atomic ops
+	mu    sync.Mutex // Locked by any who want to modify the valueStruct
+	val   atomic.Value
 }
 
 type valueStruct struct {
@@ -24,6 +25,8 @@ type valueStruct struct {
 	Handler
 	// Any parent in the named hierarchy
 	parent *Logger
+	// Any function to report Handler errors to
+	onError ErrorHandler
 }
 
 // makes sure to initialize a swapper with a value
@@ -35,6 +38,8 @@ func newSwapper() (s *swapper) {
 
 // Log sends the event down the first Handler chain, it finds in the Logger tree.
 // NB: This is different from pythong "logging" in that only one Handler is activated
+// Handler errors are reported to the ErrorHandler, and if no Handler logs the event
+// it's given to any last resort Writer.
 func (h *swapper) Log(e *event) (err error) {
 
 	// try the local handler
@@ -43,47 +48,93 @@ func (h *swapper) Log(e *event) (err error) {
 
 	if v.Handler != nil {
 		err = v.Handler.Log(Event{e})
-		if err == nil {
+		if err != nil {
+			h.failed(v.Handler, e, err)
+		}
+		if err == nil || partlyLogged(err) {
+			atomic.AddUint64(&h.stats.handled, 1)
 			freePoolEvent(e)
 			return
 		}
 	}
 	// Either no handler, or an error was returned
-	// Have to try parents. Walk the name-tree to find the first handler, not returning an error
-	cur := v.parent.h
+	// Have to try parents. Walk the name-tree to find the first handler logging the event
+	cur := v.parent
 	for cur != nil {
-		v, _ := cur.val.Load().(valueStruct) // must be valid
+		v, _ := cur.h.val.Load().(valueStruct) // must be valid
 		if v.Handler != nil {
 			err = v.Handler.Log(Event{e})
-			if err == nil {
+			if err != nil {
+				h.failed(v.Handler, e, err)
+			}
+			if err == nil || partlyLogged(err) {
+				atomic.AddUint64(&h.stats.handled, 1)
 				freePoolEvent(e)
 				return
 			}
 		}
-		cur = v.parent.h
+		cur = v.parent
 	}
 
-	freePoolEvent(e)
 	if err == nil {
 		err = ErrNotLogged
 	}
+	if !logLastResort(e, err) {
+		atomic.AddUint64(&h.stats.dropped, 1)
+	}
+	freePoolEvent(e)
 	return
 }
 
+// failed counts and reports the errors of a Handler failing to log an event.
+func (h *swapper) failed(handler Handler, e *event, err error) {
+	fn := h.errorHandler()
+	eachHandlerError(handler, err, func(handler Handler, err error) {
+		atomic.AddUint64(&h.stats.failed, 1)
+		if fn != nil {
+			fn(handler, Event{e}, err)
+		}
+	})
+}
+
+// errorHandler returns the first ErrorHandler found in the Logger tree.
+func (h *swapper) errorHandler() ErrorHandler {
+	for h != nil {
+		v := h.val.Load().(valueStruct)
+		if v.onError != nil {
+			return v.onError
+		}
+		if v.parent == nil {
+			break
+		}
+		h = v.parent.h
+	}
+	return nil
+}
+
 func (s *swapper) SwapParent(new *Logger) (old *Logger) {
 	s.mu.Lock()
 	v := s.val.Load().(valueStruct)
 	old = v.parent
-	h := v.Handler
-	s.val.Store(valueStruct{Handler: h, parent: new})
+	v.parent = new
+	s.val.Store(v)
 	s.mu.Unlock()
 	return
 }
 
 func (s *swapper) SwapHandler(new Handler) {
 	s.mu.Lock()
-	p := (s.val.Load().(valueStruct)).parent
-	s.val.Store(valueStruct{Handler: new, parent: p})
+	v := s.val.Load().(valueStruct)
+	v.Handler = new
+	s.val.Store(v)
+	s.mu.Unlock()
+}
+
+func (s *swapper) SwapErrorHandler(fn ErrorHandler) {
+	s.mu.Lock()
+	v := s.val.Load().(valueStruct)
+	v.onError = fn
+	s.val.Store(v)
 	s.mu.Unlock()
 }
 
@@ -0,0 +1,127 @@
+package log
+
+import (
+	"time"
+)
+
+// TimeFormat selects the layout of timestamps written by the stdformatter.
+// The timestamp is written when any of the Ldate, Ltime or Lmicroseconds flags
+// are set. With the default TimeStd layout these flags also decide the layout,
+// like in the standard library. Any other TimeFormat overrides the layout.
+type TimeFormat int
+
+const (
+	TimeStd          TimeFormat = iota // Layout controlled by Ldate/Ltime/Lmicroseconds
+	TimeRFC3339                        // 2009-01-23T01:23:23Z or 2009-01-23T01:23:23+01:00
+	TimeRFC3339Milli                   // 2009-01-23T01:23:23.123+01:00
+	TimeRFC3339Micro                   // 2009-01-23T01:23:23.123123+01:00
+	TimeRFC3339Nano                    // 2009-01-23T01:23:23.123123123+01:00 (fixed width)
+	TimeISO8601                        // 2009-01-23T01:23:23+0100
+	TimeISO8601Milli                   // 2009-01-23T01:23:23.123+0100
+	TimeISO8601Micro                   // 2009-01-23T01:23:23.123123+0100
+	TimeISO8601Nano                    // 2009-01-23T01:23:23.123123123+0100
+	TimeUnix                           // Seconds since the Unix epoch: 1232673803
+	TimeUnixMilli                      // Milliseconds since the Unix epoch: 1232673803123
+	TimeSinceStart                     // Seconds since process start: 12.345678
+)
+
+// The time the process (well - this package) was started.
+var processStart = time.Now()
+
+// TimeFormatOpt creates a HandlerOption setting the timestamp layout of a stdformatter.
+// If loc is not nil, timestamps are converted to that time zone (overriding LUTC)
+// Use time.FixedZone() to get a fixed time zone.
+func TimeFormatOpt(format TimeFormat, loc *time.Location) HandlerOption {
+	return func(c CloneableHandler) {
+		if h, ok := c.(*stdformatter); ok {
+			h.tfmt = format
+			h.tloc = loc
+		}
+	}
+}
+
+// appendTime writes the timestamp t in the given non-std format to buf.
+// It doesn't allocate.
+func appendTime(buf *[]byte, t time.Time, format TimeFormat) {
+	switch format {
+	case TimeUnix:
+		appendInt64(buf, t.Unix())
+		return
+	case TimeUnixMilli:
+		appendInt64(buf, t.UnixNano()/1e6)
+		return
+	case TimeSinceStart:
+		d := t.Sub(processStart)
+		if d < 0 {
+			*buf = append(*buf, '-')
+			d = -d
+		}
+		appendInt64(buf, int64(d/time.Second))
+		*buf = append(*buf, '.')
+		itoa(buf, int(d%time.Second)/1e3, 6)
+		return
+	}
+
+	year, month, day := t.Date()
+	itoa(buf, year, 4)
+	*buf = append(*buf, '-')
+	itoa(buf, int(month), 2)
+	*buf = append(*buf, '-')
+	itoa(buf, day, 2)
+	*buf = append(*buf, 'T')
+	hour, min, sec := t.Clock()
+	itoa(buf, hour, 2)
+	*buf = append(*buf, ':')
+	itoa(buf, min, 2)
+	*buf = append(*buf, ':')
+	itoa(buf, sec, 2)
+
+	switch format {
+	case TimeRFC3339Milli, TimeISO8601Milli:
+		*buf = append(*buf, '.')
+		itoa(buf, t.Nanosecond()/1e6, 3)
+	case TimeRFC3339Micro, TimeISO8601Micro:
+		*buf = append(*buf, '.')
+		itoa(buf, t.Nanosecond()/1e3, 6)
+	case TimeRFC3339Nano, TimeISO8601Nano:
+		*buf = append(*buf, '.')
+		itoa(buf, t.Nanosecond(), 9)
+	}
+
+	_, offset := t.Zone()
+	rfc := format <= TimeRFC3339Nano
+	if offset == 0 && rfc {
+		*buf = append(*buf, 'Z')
+		return
+	}
+	if offset < 0 {
+		*buf = append(*buf, '-')
+		offset = -offset
+	} else {
+		*buf = append(*buf, '+')
+	}
+	offset /= 60
+	itoa(buf, offset/60, 2)
+	if rfc {
+		*buf = append(*buf, ':')
+	}
+	itoa(buf, offset%60, 2)
+}
+
+// appendInt64 is itoa() for (possibly negative) 64 bit integers without padding.
+func appendInt64(buf *[]byte, i int64) {
+	if i < 0 {
+		*buf = append(*buf, '-')
+		i = -i
+	}
+	var b [20]byte
+	bp := len(b) - 1
+	for i >= 10 {
+		q := i / 10
+		b[bp] = byte('0' + i - q*10)
+		bp--
+		i = q
+	}
+	b[bp] = byte('0' + i)
+	*buf = append(*buf, b[bp:]...)
+}
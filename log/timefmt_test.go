@@ -0,0 +1,70 @@
+package log
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestAppendTime(t *testing.T) {
+	cet := time.FixedZone("CET", 3600)
+	nst := time.FixedZone("NST", -(3*3600 + 30*60))
+	ts := time.Date(2009, 1, 23, 1, 23, 23, 123456789, time.UTC)
+
+	tests := []struct {
+		format TimeFormat
+		loc    *time.Location
+		want   string
+	}{
+		{TimeRFC3339, time.UTC, "2009-01-23T01:23:23Z"},
+		{TimeRFC3339, cet, "2009-01-23T02:23:23+01:00"},
+		{TimeRFC3339Milli, cet, "2009-01-23T02:23:23.123+01:00"},
+		{TimeRFC3339Micro, nst, "2009-01-22T21:53:23.123456-03:30"},
+		{TimeRFC3339Nano, time.UTC, "2009-01-23T01:23:23.123456789Z"},
+		{TimeISO8601, time.UTC, "2009-01-23T01:23:23+0000"},
+		{TimeISO8601Milli, cet, "2009-01-23T02:23:23.123+0100"},
+		{TimeISO8601Micro, nst, "2009-01-22T21:53:23.123456-0330"},
+		{TimeISO8601Nano, cet, "2009-01-23T02:23:23.123456789+0100"},
+		{TimeUnix, cet, "1232673803"},
+		{TimeUnixMilli, time.UTC, "1232673803123"},
+	}
+
+	for _, test := range tests {
+		var buf []byte
+		appendTime(&buf, ts.In(test.loc), test.format)
+		if string(buf) != test.want {
+			t.Errorf("format %d: got %q, want %q", test.format, string(buf), test.want)
+		}
+	}
+
+	var buf []byte
+	appendTime(&buf, processStart.Add(12345678*time.Microsecond), TimeSinceStart)
+	if string(buf) != "12.345678" {
+		t.Errorf("since start: got %q", string(buf))
+	}
+}
+
+func TestTimeFormatOpt(t *testing.T) {
+	var b bytes.Buffer
+	l := New(&b, "", Ltime)
+	l.ApplyHandlerOptions(TimeFormatOpt(TimeRFC3339Milli, time.FixedZone("", 0)))
+	l.Print("hello")
+	got := b.String()
+	if len(got) != len("2009-01-23T01:23:23.123Z hello\n") || got[10] != 'T' || got[23] != 'Z' {
+		t.Errorf("unexpected output %q", got)
+	}
+}
+
+func TestAppendTimeAllocs(t *testing.T) {
+	buf := make([]byte, 0, 64)
+	ts := time.Now()
+	for _, format := range []TimeFormat{TimeRFC3339Nano, TimeISO8601Milli, TimeUnixMilli, TimeSinceStart} {
+		n := testing.AllocsPerRun(100, func() {
+			b := buf[:0]
+			appendTime(&b, ts, format)
+		})
+		if n != 0 {
+			t.Errorf("format %d: %f allocations", format, n)
+		}
+	}
+}
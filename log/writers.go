@@ -80,6 +80,12 @@ func (s *syncWriter) Write(b []byte) (n int, err error) {
 	s.mu.Unlock()
 	return
 }
+func (s *syncWriter) Chained() []interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return []interface{}{s.out}
+}
+
 func (s *syncWriter) SetOutput(w io.Writer) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -130,6 +136,14 @@ func (t *multiEvWriter) EvWrite(e Event, p []byte) (n int, err error) {
 	return len(p), nil
 }
 
+func (t *multiEvWriter) Chained() []interface{} {
+	c := make([]interface{}, len(t.writers))
+	for i, w := range t.writers {
+		c[i] = w
+	}
+	return c
+}
+
 // MultiEventWriter creates a writer that duplicates its writes to all the
 // provided writers, similar to the Unix tee(1) command, providing all
 // with the original event to do filtering.
@@ -141,9 +155,49 @@ func MultiEventWriter(writers ...EvWriter) EvWriter {
 
 // EventWriter creates a dummy EvWriter from an io.Writer
 func EventWriter(w io.Writer) EvWriter {
-	return EventWriterFunc(func(e Event, b []byte) (n int, err error) {
-		return w.Write(b)
-	})
+	return &eventWriter{w}
+}
+
+type eventWriter struct {
+	io.Writer
+}
+
+func (w *eventWriter) EvWrite(e Event, b []byte) (n int, err error) {
+	return w.Write(b)
+}
+
+func (w *eventWriter) Chained() []interface{} {
+	return []interface{}{w.Writer}
+}
+
+// FilterWriter creates an EvWriter letting a function evaluate whether to discard the
+// Event or write it to w. Like FilterHandler, but for filtering in a chain of Writers.
+// Writes without an event are always passed on.
+func FilterWriter(fn func(e Event) bool, w io.Writer) EvWriter {
+	return &filterWriter{fn: fn, w: w}
+}
+
+type filterWriter struct {
+	fn func(e Event) bool
+	w  io.Writer
+}
+
+func (f *filterWriter) EvWrite(e Event, b []byte) (n int, err error) {
+	if e != (Event{}) && !f.fn(e) {
+		return len(b), nil
+	}
+	if ew, ok := f.w.(EvWriter); ok {
+		return ew.EvWrite(e, b)
+	}
+	return f.w.Write(b)
+}
+
+func (f *filterWriter) Write(b []byte) (n int, err error) {
+	return f.w.Write(b)
+}
+
+func (f *filterWriter) Chained() []interface{} {
+	return []interface{}{f.w}
 }
 
 /// and finally:
@@ -151,10 +205,25 @@ func EventWriter(w io.Writer) EvWriter {
 // LevelFilterWriter creates a filtering EventWriter which writes whats below (or equal) max level to
 // The underlying io.Writer
 func LevelFilterWriter(max syslog.Priority, w io.Writer) EvWriter {
-	return EventWriterFunc(func(e Event, b []byte) (n int, err error) {
-		if e != (Event{}) && e.Lvl > max {
-			return 0, nil
-		}
-		return w.Write(b)
-	})
+	return &levelFilterWriter{max: max, w: w}
+}
+
+type levelFilterWriter struct {
+	max syslog.Priority
+	w   io.Writer
+}
+
+func (f *levelFilterWriter) EvWrite(e Event, b []byte) (n int, err error) {
+	if e != (Event{}) && e.Lvl > f.max {
+		return len(b), nil // filtered events are "written"
+	}
+	return f.w.Write(b)
+}
+
+func (f *levelFilterWriter) Write(b []byte) (n int, err error) {
+	return f.w.Write(b)
+}
+
+func (f *levelFilterWriter) Chained() []interface{} {
+	return []interface{}{f.w}
 }
@@ -1,6 +1,8 @@
 package metric
 
 import (
+	"context"
+	"github.com/One-com/gonelog/log"
 	"sync"
 	"time"
 )
@@ -19,6 +21,9 @@ type Client struct {
 	sink Sink
 
 	running bool
+
+	// remove the log exit handler stopping the client
+	unregister func()
 }
 
 // A single global client. Remember to set a default sink
@@ -104,7 +109,9 @@ func Start() {
 	default_client.Start()
 }
 
-// Start a stopped client
+// Start a stopped client.
+// A running client is stopped by log.Shutdown() (and thereby before log.Fatal() exits)
+// to flush its metrics.
 func (c *Client) Start() {
 	c.fmu.Lock()
 	defer c.fmu.Unlock()
@@ -121,6 +128,17 @@ func (c *Client) Start() {
 	}
 
 	c.running = true
+	c.unregister = log.RegisterExitHandler(func(ctx context.Context) {
+		done := make(chan struct{})
+		go func() {
+			c.Stop()
+			close(done)
+		}()
+		select {
+		case <-done:
+		case <-ctx.Done(): // give up on a stuck flush
+		}
+	})
 }
 
 // Stops the global default metrics client
@@ -145,6 +163,7 @@ func (c *Client) Stop() {
 	}
 	c.done.Wait()
 	c.running = false
+	c.unregister()
 }
 
 func (c *Client) register(m Meter, opts ...MOption) {
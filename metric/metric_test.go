@@ -1,6 +1,8 @@
 package metric_test
 
 import (
+	"context"
+	glog "github.com/One-com/gonelog/log"
 	"github.com/One-com/gonelog/metric"
 	"log"
 	"time"
@@ -40,3 +42,18 @@ func ExampleNewClient() {
 	
 }
 
+
+// Running clients are stopped by log.Shutdown(), flushing their metrics.
+func ExampleClient_Start() {
+	sink, err := metric.NewTestSink("prefix", 1432)
+	if err != nil {
+		log.Fatal(err)
+	}
+	flushPeriod := metric.FlushInterval(time.Hour)
+	c := metric.NewClient(sink, flushPeriod)
+	counter := c.NewCounter("counter", flushPeriod)
+	counter.Inc(2)
+
+	glog.Shutdown(context.Background())
+	// Output: prefix.counter:2|c
+}
@@ -0,0 +1,115 @@
+package syslog
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var priorityNames = [8]string{"emerg", "alert", "crit", "err", "warning", "notice", "info", "debug"}
+
+// Accepted (lower case) names when parsing a Priority.
+// Including the abbreviations used by gonelog level prefixes.
+var priorityAliases = map[string]Priority{
+	"emerg": LOG_EMERG, "emergency": LOG_EMERG, "emr": LOG_EMERG, "panic": LOG_EMERG,
+	"alert": LOG_ALERT, "alt": LOG_ALERT,
+	"crit": LOG_CRIT, "critical": LOG_CRIT, "crt": LOG_CRIT,
+	"err": LOG_ERR, "error": LOG_ERR,
+	"warning": LOG_WARNING, "warn": LOG_WARNING, "wrn": LOG_WARNING,
+	"notice": LOG_NOTICE, "not": LOG_NOTICE,
+	"info": LOG_INFO, "inf": LOG_INFO,
+	"debug": LOG_DEBUG, "dbg": LOG_DEBUG,
+}
+
+var facilityNames = map[Priority]string{
+	LOG_KERN: "kern", LOG_USER: "user", LOG_MAIL: "mail", LOG_DAEMON: "daemon",
+	LOG_AUTH: "auth", LOG_SYSLOG: "syslog", LOG_LPR: "lpr", LOG_NEWS: "news",
+	LOG_UUCP: "uucp", LOG_CRON: "cron", LOG_AUTHPRIV: "authpriv", LOG_FTP: "ftp",
+	LOG_LOCAL0: "local0", LOG_LOCAL1: "local1", LOG_LOCAL2: "local2", LOG_LOCAL3: "local3",
+	LOG_LOCAL4: "local4", LOG_LOCAL5: "local5", LOG_LOCAL6: "local6", LOG_LOCAL7: "local7",
+}
+
+// String returns the syslog name of the severity ("err", "debug", ...).
+// Priorities with a facility are returned as "facility.severity", like "local0.err".
+// Unknown priorities are returned as numbers.
+func (p Priority) String() string {
+	if p >= LOG_EMERG && p <= LOG_DEBUG {
+		return priorityNames[p]
+	}
+	if fac, ok := facilityNames[p.Facility()]; ok && p == p.Facility()|p.Severity() {
+		return fac + "." + priorityNames[p.Severity()]
+	}
+	return strconv.Itoa(int(p))
+}
+
+// ParseFacility parses a facility name ("daemon", "local0", ...) case-insensitively
+func ParseFacility(s string) (Priority, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	for fac, name := range facilityNames {
+		if name == s {
+			return fac, nil
+		}
+	}
+	return 0, fmt.Errorf("syslog: invalid facility %q", s)
+}
+
+// ParsePriority parses a severity name, abbreviation or number, case-insensitively.
+// Like "debug", "DBG", "warning", "warn", "err", "error" or "7".
+// It accepts everything String() returns: a severity with a facility like "local0.err"
+// and any number, like "131" or "-1".
+func ParsePriority(s string) (Priority, error) {
+	s = strings.TrimSpace(s)
+	if p, ok := priorityAliases[strings.ToLower(s)]; ok {
+		return p, nil
+	}
+	if i := strings.IndexByte(s, '.'); i > 0 {
+		fac, ferr := ParseFacility(s[:i])
+		sev, ok := priorityAliases[strings.ToLower(s[i+1:])]
+		if ferr == nil && ok {
+			return fac | sev, nil
+		}
+	}
+	if n, err := strconv.Atoi(s); err == nil {
+		return Priority(n), nil
+	}
+	return 0, fmt.Errorf("syslog: invalid priority %q", s)
+}
+
+// MarshalText implements encoding.TextMarshaler
+func (p Priority) MarshalText() ([]byte, error) {
+	return []byte(p.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler
+func (p *Priority) UnmarshalText(text []byte) error {
+	n, err := ParsePriority(string(text))
+	if err != nil {
+		return err
+	}
+	*p = n
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler encoding the priority as its name.
+func (p Priority) MarshalJSON() ([]byte, error) {
+	return json.Marshal(p.String())
+}
+
+// UnmarshalJSON implements json.Unmarshaler accepting both names and numbers.
+func (p *Priority) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		var n int
+		if err := json.Unmarshal(data, &n); err != nil {
+			return fmt.Errorf("syslog: invalid priority %s", data)
+		}
+		s = strconv.Itoa(n)
+	}
+	return p.UnmarshalText([]byte(s))
+}
+
+// Set implements flag.Value
+func (p *Priority) Set(s string) error {
+	return p.UnmarshalText([]byte(s))
+}
@@ -0,0 +1,84 @@
+package syslog
+
+import (
+	"encoding/json"
+	"flag"
+	"testing"
+)
+
+func TestParsePriority(t *testing.T) {
+	tests := map[string]Priority{
+		"debug": LOG_DEBUG, "DBG": LOG_DEBUG, "warning": LOG_WARNING, "warn": LOG_WARN,
+		"err": LOG_ERR, "Error": LOG_ERROR, "7": LOG_DEBUG, " 0 ": LOG_EMERG, "crit": LOG_CRIT,
+		"8": LOG_USER | LOG_EMERG, "-1": -1, "Local0.Error": LOG_LOCAL0 | LOG_ERR, "daemon.warn": LOG_DAEMON | LOG_WARN,
+	}
+	for s, want := range tests {
+		if p, err := ParsePriority(s); err != nil || p != want {
+			t.Errorf("ParsePriority(%q) = %v, %v", s, p, err)
+		}
+	}
+	for _, s := range []string{"", "verbose", "local9.err", "local0.verbose", ".err", "1.5"} {
+		if _, err := ParsePriority(s); err == nil {
+			t.Errorf("ParsePriority(%q) didn't fail", s)
+		}
+	}
+}
+
+func TestPriorityMarshal(t *testing.T) {
+	var cfg struct{ Level Priority }
+	if err := json.Unmarshal([]byte(`{"Level":"warn"}`), &cfg); err != nil || cfg.Level != LOG_WARN {
+		t.Errorf("unmarshal name: %v %v", cfg.Level, err)
+	}
+	if err := json.Unmarshal([]byte(`{"Level":3}`), &cfg); err != nil || cfg.Level != LOG_ERR {
+		t.Errorf("unmarshal number: %v %v", cfg.Level, err)
+	}
+	if b, _ := json.Marshal(cfg); string(b) != `{"Level":"err"}` {
+		t.Errorf("marshal: %s", b)
+	}
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	p := LOG_INFO
+	fs.Var(&p, "level", "log level")
+	if err := fs.Parse([]string{"-level", "debug"}); err != nil || p != LOG_DEBUG {
+		t.Errorf("flag: %v %v", p, err)
+	}
+	if s := (LOG_LOCAL0 | LOG_ERR).String(); s != "local0.err" {
+		t.Errorf("priority with facility string: %s", s)
+	}
+	if Priority(-1).String() != "-1" {
+		t.Errorf("unknown priority string: %s", Priority(-1))
+	}
+}
+
+func TestFacility(t *testing.T) {
+	p := LOG_LOCAL7 | LOG_DEBUG
+	if p != 191 || p.Facility() != LOG_LOCAL7 || p.Severity() != LOG_DEBUG {
+		t.Errorf("bad priority encoding %d", p)
+	}
+	if f, err := ParseFacility("Daemon"); err != nil || f != LOG_DAEMON {
+		t.Errorf("ParseFacility: %v %v", f, err)
+	}
+}
+
+func TestPriorityRoundTrip(t *testing.T) {
+	var prios []Priority
+	for fac := range facilityNames {
+		for sev := LOG_EMERG; sev <= LOG_DEBUG; sev++ {
+			prios = append(prios, fac|sev)
+		}
+	}
+	// unknown priorities are numbers
+	prios = append(prios, -1, 12*8, 1000)
+	for _, p := range prios {
+		text, _ := p.MarshalText()
+		var q Priority
+		if err := q.UnmarshalText(text); err != nil || q != p {
+			t.Errorf("text %d: %q -> %d %v", p, text, q, err)
+		}
+		js, _ := json.Marshal(p)
+		q = 0
+		if err := json.Unmarshal(js, &q); err != nil || q != p {
+			t.Errorf("JSON %d: %s -> %d %v", p, js, q, err)
+		}
+	}
+}
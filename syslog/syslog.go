@@ -15,6 +15,52 @@ const (
 	LOG_DEBUG
 )
 
+// Facility. Or'ed with a severity to get the full syslog priority.
+
+const (
+	// From /usr/include/sys/syslog.h.
+	// These are the same up to LOG_FTP on Linux, BSD, and OS X.
+	LOG_KERN Priority = iota << 3
+	LOG_USER
+	LOG_MAIL
+	LOG_DAEMON
+	LOG_AUTH
+	LOG_SYSLOG
+	LOG_LPR
+	LOG_NEWS
+	LOG_UUCP
+	LOG_CRON
+	LOG_AUTHPRIV
+	LOG_FTP
+	_ // unused
+	_ // unused
+	_ // unused
+	_ // unused
+	LOG_LOCAL0
+	LOG_LOCAL1
+	LOG_LOCAL2
+	LOG_LOCAL3
+	LOG_LOCAL4
+	LOG_LOCAL5
+	LOG_LOCAL6
+	LOG_LOCAL7
+)
+
+const (
+	severityMask = 0x07
+	facilityMask = 0xf8
+)
+
+// Severity returns the severity part of a priority with a facility.
+func (p Priority) Severity() Priority {
+	return p & severityMask
+}
+
+// Facility returns the facility part of a priority.
+func (p Priority) Facility() Priority {
+	return p & facilityMask
+}
+
 // aliases
 
 const (